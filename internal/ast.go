@@ -1,5 +1,10 @@
 package godb
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Ast struct {
 	Statements []*Statement
 }
@@ -10,39 +15,480 @@ const (
 	SelectStmtKind StatementKind = iota
 	CreateStmtKind
 	InsertStmtKind
+	UpdateStmtKind
+	DeleteStmtKind
+	DropStmtKind
+	AlterStmtKind
 )
 
 type expressionKind uint
 
 const (
 	literalKind expressionKind = iota
+	binaryKind
+	unaryKind
+	functionKind
+	subqueryKind
+	existsKind
+	inKind
+	isNullKind
 )
 
+type binaryExpression struct {
+	a  *expression
+	b  *expression
+	op token
+}
+
+type unaryExpression struct {
+	operand *expression
+	op      token
+}
+
+// functionCall is a call to a scalar or aggregate function, asterisk is set
+// for COUNT(*)
+type functionCall struct {
+	name     token
+	args     []*expression
+	asterisk bool
+	distinct bool
+}
+
+// inExpression tests the operand against a list of values or the rows of a
+// subquery
+type inExpression struct {
+	operand  *expression
+	list     []*expression
+	subquery *SelectStatement
+	not      bool
+}
+
+// isNullExpression tests whether the operand is NULL, or is not
+type isNullExpression struct {
+	operand *expression
+	not     bool
+}
+
+// expression is a union of the kinds of expressions, a literal identifier
+// being a column reference optionally qualified by a table name or alias and
+// a literal keyword being NULL, TRUE or FALSE. A literal string with a
+// typeName is a value of that type. subquery holds the select of scalar
+// subqueries and EXISTS.
 type expression struct {
-	literal *token
-	kind    expressionKind
+	literal   *token
+	qualifier *token
+	typeName  *token
+	binary    *binaryExpression
+	unary     *unaryExpression
+	function  *functionCall
+	subquery  *SelectStatement
+	in        *inExpression
+	isNull    *isNullExpression
+	kind      expressionKind
+}
+
+// generateCode renders the expression back as SQL, parenthesising operands
+// only where the operator precedence requires it
+func (e *expression) generateCode() string {
+	switch e.kind {
+	case literalKind:
+		if e.literal.kind == STRING {
+			s := fmt.Sprintf("'%s'", strings.ReplaceAll(e.literal.value, "'", "''"))
+			if e.typeName != nil {
+				return e.typeName.value + " " + s
+			}
+			return s
+		}
+		if e.literal.kind == HEXSTRING {
+			return fmt.Sprintf("x'%s'", e.literal.value)
+		}
+		if e.qualifier != nil {
+			return e.qualifier.value + "." + e.literal.value
+		}
+		return e.literal.value
+	case unaryKind:
+		operand := e.unary.operand.generateCode()
+		if e.unary.operand.kind == binaryKind {
+			operand = "(" + operand + ")"
+		}
+		if e.unary.op.kind == KEYWORD {
+			return e.unary.op.value + " " + operand
+		}
+		return e.unary.op.value + operand
+	case binaryKind:
+		power := binaryOperatorPower(&e.binary.op)
+		a := e.binary.a.generateCode()
+		if e.binary.a.kind == binaryKind && binaryOperatorPower(&e.binary.a.binary.op) < power {
+			a = "(" + a + ")"
+		}
+		b := e.binary.b.generateCode()
+		if e.binary.b.kind == binaryKind && binaryOperatorPower(&e.binary.b.binary.op) <= power {
+			b = "(" + b + ")"
+		}
+		return fmt.Sprintf("%s %s %s", a, e.binary.op.value, b)
+	case functionKind:
+		if e.function.asterisk {
+			return e.function.name.value + "(*)"
+		}
+		if e.function.name.value == "extract" && len(e.function.args) == 2 && e.function.args[0].kind == literalKind {
+			return fmt.Sprintf("extract(%s from %s)", e.function.args[0].literal.value, e.function.args[1].generateCode())
+		}
+		var args []string
+		for _, arg := range e.function.args {
+			args = append(args, arg.generateCode())
+		}
+		distinct := ""
+		if e.function.distinct {
+			distinct = "distinct "
+		}
+		return fmt.Sprintf("%s(%s%s)", e.function.name.value, distinct, strings.Join(args, ", "))
+	case subqueryKind:
+		return "(" + e.subquery.generateCode() + ")"
+	case existsKind:
+		return "exists (" + e.subquery.generateCode() + ")"
+	case inKind:
+		operand := e.in.operand.generateCode()
+		if e.in.operand.kind == binaryKind {
+			operand = "(" + operand + ")"
+		}
+		not := ""
+		if e.in.not {
+			not = "not "
+		}
+		if e.in.subquery != nil {
+			return fmt.Sprintf("%s %sin (%s)", operand, not, e.in.subquery.generateCode())
+		}
+		var list []string
+		for _, exp := range e.in.list {
+			list = append(list, exp.generateCode())
+		}
+		return fmt.Sprintf("%s %sin (%s)", operand, not, strings.Join(list, ", "))
+	case isNullKind:
+		operand := e.isNull.operand.generateCode()
+		if e.isNull.operand.kind == binaryKind {
+			operand = "(" + operand + ")"
+		}
+		if e.isNull.not {
+			return operand + " is not null"
+		}
+		return operand + " is null"
+	}
+	return ""
 }
 
 // Types of statements
 
+// selectItem is an expression or an asterisk (optionally qualified by a
+// table name) in the select list
+type selectItem struct {
+	exp      *expression
+	asterisk bool
+	table    *token
+	as       *token
+}
+
+func (si *selectItem) generateCode() string {
+	if si.asterisk {
+		if si.table != nil {
+			return si.table.value + ".*"
+		}
+		return "*"
+	}
+	if si.as != nil {
+		return si.exp.generateCode() + " as " + si.as.value
+	}
+	return si.exp.generateCode()
+}
+
+// name returns the output column name of the item, its alias if it has one
+func (si *selectItem) name() string {
+	if si.as != nil {
+		return si.as.value
+	}
+	if si.exp.kind == literalKind && si.exp.literal.kind == IDENTIFIER {
+		return si.exp.literal.value
+	}
+	return si.exp.generateCode()
+}
+
+// orderByItem is a sort key, an expression over the input rows, an output
+// column name or the 1-based position of an output column
+type orderByItem struct {
+	exp        *expression
+	desc       bool
+	nullsFirst bool
+}
+
+type joinKind uint
+
+const (
+	innerJoinKind joinKind = iota
+	leftJoinKind
+	rightJoinKind
+	fullJoinKind
+	crossJoinKind
+)
+
+// joinClause joins two FROM items, either ON a predicate or USING columns
+// both sides have
+type joinClause struct {
+	kind  joinKind
+	left  *fromItem
+	right *fromItem
+	on    *expression
+	using []token
+}
+
+type fromKind uint
+
+const (
+	tableFromKind fromKind = iota
+	joinFromKind
+	subqueryFromKind
+)
+
+// fromItem is a table or a subquery, optionally aliased, or a join of two
+// FROM items
+type fromItem struct {
+	table    *token
+	alias    *token
+	join     *joinClause
+	subquery *SelectStatement
+	kind     fromKind
+}
+
+func (f *fromItem) generateCode() string {
+	var code string
+	switch f.kind {
+	case tableFromKind:
+		code = f.table.value
+	case subqueryFromKind:
+		code = "(" + f.subquery.generateCode() + ")"
+	case joinFromKind:
+		jc := f.join
+		joins := map[joinKind]string{
+			innerJoinKind: "join",
+			leftJoinKind:  "left join",
+			rightJoinKind: "right join",
+			fullJoinKind:  "full join",
+			crossJoinKind: "cross join",
+		}
+		code = fmt.Sprintf("%s %s %s", jc.left.generateCode(), joins[jc.kind], jc.right.generateCode())
+		if jc.on != nil {
+			code += " on " + jc.on.generateCode()
+		}
+		if len(jc.using) > 0 {
+			var using []string
+			for _, col := range jc.using {
+				using = append(using, col.value)
+			}
+			code += " using (" + strings.Join(using, ", ") + ")"
+		}
+	}
+
+	if f.alias != nil {
+		code += " as " + f.alias.value
+	}
+	return code
+}
+
+// setOperation combines the results of two selects with UNION, INTERSECT or
+// EXCEPT
+type setOperation struct {
+	op    token
+	all   bool
+	left  *SelectStatement
+	right *SelectStatement
+}
+
+// SelectStatement is either a single select or, when setOp is set, a set
+// operation whose result is ordered and limited by the statement
 type SelectStatement struct {
-	item []*expression
-	from token
+	distinct bool
+	item     []*selectItem
+	from     *fromItem
+	where    *expression
+	groupBy  []*expression
+	having   *expression
+	orderBy  []*orderByItem
+	limit    *expression
+	offset   *expression
+	setOp    *setOperation
 }
 
+// InsertStatement adds rows of values, or the rows of a query, for the
+// columns listed, every column of the table in order without a list
 type InsertStatement struct {
-	table  token
-	values *[]*expression
+	table   token
+	columns []token
+	values  [][]*expression
+	query   *SelectStatement
+}
+
+// setClause assigns the value of an expression to a column
+type setClause struct {
+	column token
+	value  *expression
+}
+
+type UpdateStatement struct {
+	table token
+	set   []*setClause
+	where *expression
+}
+
+// DeleteStatement removes the rows of a table matching the WHERE clause, all
+// of them without one as with TRUNCATE
+type DeleteStatement struct {
+	table token
+	where *expression
 }
 
+// columnDefinition is a column of a CREATE TABLE or ALTER TABLE ADD COLUMN
+// and the constraints declared along with it
 type columnDefinition struct {
-	name     token
-	datatype token
+	name       token
+	datatype   token
+	def        *expression
+	notNull    bool
+	unique     bool
+	primaryKey bool
+	check      *expression
+	references *references
+}
+
+type referentialAction uint
+
+const (
+	restrictAction referentialAction = iota
+	cascadeAction
+	setNullAction
+	setDefaultAction
+)
+
+// references is the REFERENCES clause of a foreign key, columns being empty
+// to reference the primary key of the table
+type references struct {
+	table    token
+	columns  []token
+	onDelete referentialAction
+	onUpdate referentialAction
+}
+
+type constraintKind uint
+
+const (
+	uniqueConstraintKind constraintKind = iota
+	primaryKeyConstraintKind
+	checkConstraintKind
+	foreignKeyConstraintKind
+)
+
+// tableConstraint is a constraint declared apart from the columns of a
+// CREATE TABLE, over the columns listed or, for CHECK, the whole row
+type tableConstraint struct {
+	columns    []token
+	check      *expression
+	references *references
+	kind       constraintKind
 }
 
 type CreateStatement struct {
-	name token
-	cols *[]*columnDefinition
+	name        token
+	cols        *[]*columnDefinition
+	constraints []*tableConstraint
+	ifNotExists bool
+}
+
+type alterKind uint
+
+const (
+	addColumnAlterKind alterKind = iota
+	dropColumnAlterKind
+	renameColumnAlterKind
+	renameTableAlterKind
+)
+
+// AlterStatement changes the definition of a table: column is the column
+// added, name the column dropped or renamed and newName the new name of the
+// column or table
+type AlterStatement struct {
+	table   token
+	column  *columnDefinition
+	name    *token
+	newName *token
+	kind    alterKind
+}
+
+type DropStatement struct {
+	names    []token
+	ifExists bool
+}
+
+// generateCode renders the statement back as SQL
+func (s *SelectStatement) generateCode() string {
+	var parts []string
+	if s.setOp != nil {
+		op := s.setOp.op.value
+		if s.setOp.all {
+			op += " all"
+		}
+		parts = append(parts, s.setOp.left.generateCode(), op, s.setOp.right.generateCode())
+	} else {
+		parts = append(parts, "select")
+		if s.distinct {
+			parts = append(parts, "distinct")
+		}
+
+		var items []string
+		for _, item := range s.item {
+			items = append(items, item.generateCode())
+		}
+		parts = append(parts, strings.Join(items, ", "))
+
+		if s.from != nil {
+			parts = append(parts, "from", s.from.generateCode())
+		}
+		if s.where != nil {
+			parts = append(parts, "where", s.where.generateCode())
+		}
+		if len(s.groupBy) > 0 {
+			var groupBy []string
+			for _, exp := range s.groupBy {
+				groupBy = append(groupBy, exp.generateCode())
+			}
+			parts = append(parts, "group by", strings.Join(groupBy, ", "))
+		}
+		if s.having != nil {
+			parts = append(parts, "having", s.having.generateCode())
+		}
+	}
+
+	if len(s.orderBy) > 0 {
+		var orderBy []string
+		for _, item := range s.orderBy {
+			code := item.exp.generateCode()
+			if item.desc {
+				code += " desc"
+			}
+			if item.nullsFirst != item.desc {
+				if item.nullsFirst {
+					code += " nulls first"
+				} else {
+					code += " nulls last"
+				}
+			}
+			orderBy = append(orderBy, code)
+		}
+		parts = append(parts, "order by", strings.Join(orderBy, ", "))
+	}
+	if s.limit != nil {
+		parts = append(parts, "limit", s.limit.generateCode())
+	}
+	if s.offset != nil {
+		parts = append(parts, "offset", s.offset.generateCode())
+	}
+	return strings.Join(parts, " ")
 }
 
 // Statement TODO: Think of a better way to build this union
@@ -50,5 +496,9 @@ type Statement struct {
 	SelectStatement *SelectStatement
 	CreateStatement *CreateStatement
 	InsertStatement *InsertStatement
+	UpdateStatement *UpdateStatement
+	DeleteStatement *DeleteStatement
+	DropStatement   *DropStatement
+	AlterStatement  *AlterStatement
 	Kind            StatementKind
 }
@@ -0,0 +1,63 @@
+package godb
+
+import "testing"
+
+func TestGenerateCode(t *testing.T) {
+	// operands are only parenthesised where precedence requires it
+	tests := []struct {
+		item string
+		want string
+	}{
+		{"a", "a"},
+		{"'text'", "'text'"},
+		{"(1 + 2) * 3", "(1 + 2) * 3"},
+		{"1 + (2 * 3)", "1 + 2 * 3"},
+		{"(1 - 2) - 3", "1 - 2 - 3"},
+		{"1 - (2 - 3)", "1 - (2 - 3)"},
+		{"-(a + 1)", "-(a + 1)"},
+		{"- a", "-a"},
+		{"NOT (a = 1 OR b = 2)", "not (a = 1 or b = 2)"},
+		{"a || 'x' = b", "a || 'x' = b"},
+		{"COUNT(*)", "count(*)"},
+		{"Sum(DISTINCT a + 1) * 2", "sum(distinct a + 1) * 2"},
+		{"max((a))", "max(a)"},
+		{"a IS NULL AND NOT b IS NOT NULL", "a is null and not b is not null"},
+		{"(a + 1) IS NULL", "(a + 1) is null"},
+		{"NULL", "null"},
+	}
+	for _, test := range tests {
+		ast, err := parse("SELECT " + test.item + " FROM t;")
+		if err != nil {
+			t.Errorf("%s: %v", test.item, err)
+			continue
+		}
+		if got := ast.Statements[0].SelectStatement.item[0].exp.generateCode(); got != test.want {
+			t.Errorf("%s: generated %s, want %s", test.item, got, test.want)
+		}
+	}
+}
+
+func TestGenerateSelectCode(t *testing.T) {
+	// rendered selects parse back into the same select
+	sources := []string{
+		"select distinct a, t.*, b + 1 as c from t where a in (1, 2) and not b not in (select x from u) group by a, b having count(*) > 1 order by 1 desc nulls last, a limit 10 offset 2",
+		"select * from t as x left join (select a from u) as y on x.a = y.a join v using (k) where exists (select 1 from w where w.a = x.a)",
+		"select a from t union all select (select max(b) from u) intersect select a from v except select 1",
+	}
+	for _, source := range sources {
+		ast, err := parse(source + ";")
+		if err != nil {
+			t.Errorf("%s: %v", source, err)
+			continue
+		}
+		code := ast.Statements[0].SelectStatement.generateCode()
+		reparsed, err := parse(code + ";")
+		if err != nil {
+			t.Errorf("%s: generated %s, which does not parse: %v", source, code, err)
+			continue
+		}
+		if again := reparsed.Statements[0].SelectStatement.generateCode(); again != code {
+			t.Errorf("%s: generated %s, then %s", source, code, again)
+		}
+	}
+}
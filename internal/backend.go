@@ -2,6 +2,7 @@ package godb
 
 import (
 	"errors"
+	"time"
 )
 
 type ColumnType uint
@@ -9,31 +10,119 @@ type ColumnType uint
 const (
 	TextType ColumnType = iota
 	IntType
+	FloatType
+	BoolType
+	DateType
+	TimeType
+	TimestampType
+	TimestampTzType
+	IntervalType
+	NumericType
+	BlobType
 )
 
+func (typ ColumnType) String() string {
+	switch typ {
+	case TextType:
+		return "text"
+	case IntType:
+		return "int"
+	case FloatType:
+		return "double precision"
+	case BoolType:
+		return "boolean"
+	case DateType:
+		return "date"
+	case TimeType:
+		return "time"
+	case TimestampType:
+		return "timestamp"
+	case TimestampTzType:
+		return "timestamp with time zone"
+	case IntervalType:
+		return "interval"
+	case NumericType:
+		return "numeric"
+	case BlobType:
+		return "bytea"
+	}
+	return "unknown"
+}
+
+// Cell is a value of a result, decoded as the type of its column. Decoding
+// a NULL cell, or one of another type, fails.
 type Cell interface {
-	AsText() string
-	AsInt() int64
+	AsText() (string, error)
+	AsInt() (int64, error)
+	AsFloat() (float64, error)
+	AsBool() (bool, error)
+	AsTime() (time.Time, error)
+	AsInterval() (Interval, error)
+	AsDecimal() (Decimal, error)
+	AsBytes() ([]byte, error)
+	IsNull() bool
+}
+
+type ResultColumn struct {
+	Type ColumnType
+	Name string
 }
 
 type Results struct {
-	Columns []struct {
-		Type ColumnType
-		Name string
-	}
-	Row [][]Cell
+	Columns []ResultColumn
+	Row     [][]Cell
 }
 
 var (
-	ErrTableDoesNotExist  = errors.New("table does not exist")
-	ErrColumnDoesNotExist = errors.New("column does not exist")
-	ErrInvalidSelectItem  = errors.New("select item is not valid")
-	ErrInvalidDatatype    = errors.New("invalid datatype")
-	ErrMissingValues      = errors.New("missing values")
+	ErrTableDoesNotExist    = errors.New("table does not exist")
+	ErrTableAlreadyExists   = errors.New("table already exists")
+	ErrColumnDoesNotExist   = errors.New("column does not exist")
+	ErrInvalidSelectItem    = errors.New("select item is not valid")
+	ErrInvalidDatatype      = errors.New("invalid datatype")
+	ErrMissingValues        = errors.New("missing values")
+	ErrTooManyValues        = errors.New("more values than columns")
+	ErrInvalidOperands      = errors.New("operands have incompatible types")
+	ErrInvalidPredicate     = errors.New("predicate is not a boolean expression")
+	ErrDivisionByZero       = errors.New("division by zero")
+	ErrIntegerOutOfRange    = errors.New("integer out of range")
+	ErrIntervalOutOfRange   = errors.New("interval out of range")
+	ErrDateOutOfRange       = errors.New("date or timestamp out of range")
+	ErrInvalidSortKey       = errors.New("invalid sort key")
+	ErrInvalidLimit         = errors.New("LIMIT and OFFSET must be non-negative integers")
+	ErrFunctionDoesNotExist = errors.New("function does not exist")
+	ErrInvalidArguments     = errors.New("invalid function arguments")
+	ErrInvalidAggregate     = errors.New("aggregate functions are not allowed here")
+	ErrInvalidSubquery      = errors.New("subqueries are not allowed here")
+	ErrColumnNotGrouped     = errors.New("column must appear in GROUP BY or be used in an aggregate function")
+	ErrColumnCountMismatch  = errors.New("each side of a set operation must have the same number of columns")
+	ErrColumnTypeMismatch   = errors.New("each side of a set operation must have the same column types")
+	ErrAmbiguousColumn      = errors.New("column reference is ambiguous")
+	ErrDuplicateTableName   = errors.New("table name specified more than once")
+	ErrSubqueryColumns      = errors.New("subquery must return only one column")
+	ErrSubqueryRows         = errors.New("more than one row returned by a subquery used as an expression")
+	ErrDuplicateColumnName  = errors.New("column name specified more than once")
+	ErrValueTypeMismatch    = errors.New("value does not match the column type")
+	ErrMultiplePrimaryKeys  = errors.New("multiple primary keys are not allowed")
+	ErrNotNullViolation     = errors.New("null value violates not-null constraint")
+	ErrUniqueViolation      = errors.New("duplicate key value violates unique constraint")
+	ErrCheckViolation       = errors.New("row violates check constraint")
+	ErrInvalidForeignKey    = errors.New("foreign key must reference the columns of a unique or primary key of the same types")
+	ErrForeignKeyViolation  = errors.New("row violates foreign key constraint, no matching row is referenced")
+	ErrForeignKeyReferenced = errors.New("row is still referenced by a foreign key")
+	ErrTableReferenced      = errors.New("table is referenced by a foreign key")
+	ErrColumnReferenced     = errors.New("column is referenced by a foreign key")
+	ErrNullCell             = errors.New("cell is NULL")
+	ErrInvalidCell          = errors.New("cell does not hold a value of the type")
+	ErrInvalidField         = errors.New("invalid date or time field")
+	ErrNumericOverflow      = errors.New("numeric field overflow")
 )
 
 type Backend interface {
 	CreateTable(statement *CreateStatement) error
+	DropTable(statement *DropStatement) error
+	AlterTable(statement *AlterStatement) error
 	Insert(statement *InsertStatement) error
 	Select(statement *SelectStatement) (*Results, error)
+	Update(statement *UpdateStatement) (int, error)
+	Delete(statement *DeleteStatement) (int, error)
 }
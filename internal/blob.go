@@ -0,0 +1,56 @@
+package godb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// AsBytes decodes the cell as a binary string, failing if it is NULL
+func (mc MemoryCell) AsBytes() ([]byte, error) {
+	if mc == nil {
+		return nil, ErrNullCell
+	}
+	return []byte(mc), nil
+}
+
+// blobCell encodes a binary string as its bytes, never as nil so that the
+// empty string is not taken for NULL
+func blobCell(b []byte) MemoryCell {
+	c := make(MemoryCell, len(b))
+	copy(c, b)
+	return c
+}
+
+// parseBlob reads a binary string as PostgreSQL does, in hex after a \x,
+// e.g. '\xdeadbeef', or otherwise as the bytes of the text
+func parseBlob(s string) (MemoryCell, error) {
+	if !strings.HasPrefix(s, `\x`) {
+		return blobCell([]byte(s)), nil
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s' is not a bytea", ErrInvalidCell, s)
+	}
+	return blobCell(b), nil
+}
+
+// formatBlob renders a binary string in hex, e.g. \xdeadbeef
+func formatBlob(b []byte) string {
+	return `\x` + hex.EncodeToString(b)
+}
+
+// isBlobType reports whether values of the type can be used as binary
+// strings
+func isBlobType(typ ColumnType) bool {
+	return typ == BlobType || typ == nullType
+}
+
+// concatType returns the type of the concatenation of values of the types a
+// and b: binary strings if both are, text otherwise
+func concatType(a, b ColumnType) ColumnType {
+	if isBlobType(a) && isBlobType(b) && (a == BlobType || b == BlobType) {
+		return BlobType
+	}
+	return TextType
+}
@@ -0,0 +1,27 @@
+package godb
+
+import "testing"
+
+func TestBlob(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE files (id int, hash bytea UNIQUE, payload blob);"},
+		{sql: "INSERT INTO files VALUES (1, x'DEADBEEF', X'00ff00'), (2, '\\x0102', 'héllo'), (3, x'', NULL);"},
+		{sql: "SELECT id, hash, payload FROM files ORDER BY id;", rows: []string{
+			`1, \xdeadbeef, \x00ff00`,
+			`2, \x0102, \x68c3a96c6c6f`,
+			`3, \x, NULL`,
+		}},
+		{sql: "INSERT INTO files (id, hash) VALUES (4, '\\xDEADBEEF');", err: ErrUniqueViolation},
+		{sql: "INSERT INTO files (id, hash) VALUES (4, 1);", err: ErrValueTypeMismatch},
+		{sql: "SELECT x'abc';", err: ErrInvalidCell},
+		{sql: "SELECT id FROM files WHERE hash = x'deadbeef';", rows: []string{"1"}},
+		{sql: "SELECT id FROM files ORDER BY hash;", rows: []string{"3", "2", "1"}},
+		{sql: "SELECT hash || x'00' FROM files WHERE id = 1;", rows: []string{`\xdeadbeef00`}},
+
+		// lengths and positions count bytes, but characters of text
+		{sql: "SELECT length(payload), substr(payload, 2, 2), length('héllo'), substr('héllo', 2, 3) FROM files WHERE id = 2;", rows: []string{`6, \xc3a9, 5, éll`}},
+		{sql: "SELECT substr('abc', -1, 3), substr('abc', -5, 2), substr('abc', 5), substr('abc', 2, 0), substr('abc', 2);", rows: []string{"a, , , , bc"}},
+		{sql: "SELECT substr('abc', 1, -1);", err: ErrInvalidArguments},
+		{sql: "SELECT length(1);", err: ErrInvalidArguments},
+	})
+}
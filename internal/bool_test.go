@@ -0,0 +1,49 @@
+package godb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBooleans(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE flags (id int, on_call boolean DEFAULT false, admin boolean);"},
+		{sql: "INSERT INTO flags VALUES (1, true, false), (2, 'yes', 'off'), (3, 'F', NULL), (4, 1 > 2, 1 < 2);"},
+		{sql: "INSERT INTO flags (id) VALUES (5);"},
+		{sql: "INSERT INTO flags VALUES (6, 'maybe', true);", err: ErrValueTypeMismatch},
+		{sql: "INSERT INTO flags VALUES (6, 1, true);", err: ErrValueTypeMismatch},
+		{sql: "SELECT * FROM flags;", rows: []string{
+			"1, true, false", "2, true, false", "3, false, NULL", "4, false, true", "5, false, NULL",
+		}},
+
+		// boolean columns are predicates
+		{sql: "SELECT id FROM flags WHERE on_call;", rows: []string{"1", "2"}},
+		{sql: "SELECT id FROM flags WHERE NOT admin;", rows: []string{"1", "2"}},
+		{sql: "SELECT id FROM flags WHERE on_call OR admin;", rows: []string{"1", "2", "4"}},
+		{sql: "SELECT id FROM flags WHERE admin IS NULL AND NOT on_call;", rows: []string{"3", "5"}},
+		{sql: "SELECT id FROM flags WHERE on_call <> admin;", rows: []string{"1", "2", "4"}},
+		{sql: "SELECT id FROM flags WHERE id;", err: ErrInvalidPredicate},
+		{sql: "SELECT id FROM flags WHERE on_call AND id;", err: ErrInvalidPredicate},
+		{sql: "SELECT id FROM flags WHERE on_call = 1;", err: ErrInvalidOperands},
+
+		// predicates are values, NULL when unknown
+		{sql: "SELECT id, id > 2, admin OR on_call, NOT admin FROM flags WHERE id < 4;", rows: []string{
+			"1, false, true, true", "2, false, true, true", "3, true, NULL, NULL",
+		}},
+		{sql: "SELECT 1 IN (1, 2), EXISTS (SELECT 1 WHERE false), NULL IS NULL;", rows: []string{"true, false, true"}},
+		{sql: "SELECT on_call, count(*) FROM flags GROUP BY on_call ORDER BY on_call;", rows: []string{"false, 3", "true, 2"}},
+		{sql: "SELECT max(admin), min(on_call) FROM flags;", rows: []string{"true, false"}},
+		{sql: "UPDATE flags SET admin = NOT on_call WHERE admin IS NULL;"},
+		{sql: "SELECT count(*) FROM flags WHERE admin;", rows: []string{"3"}},
+		{sql: "SELECT true + 1;", err: ErrInvalidOperands},
+	})
+}
+
+func TestMemoryCellBool(t *testing.T) {
+	if b, err := boolCell(true).AsBool(); err != nil || !b {
+		t.Errorf("got %t, %v decoding true, want true", b, err)
+	}
+	if _, err := intCell(1).AsBool(); !errors.Is(err, ErrInvalidCell) {
+		t.Errorf("got %v decoding an int as a boolean, want %v", err, ErrInvalidCell)
+	}
+}
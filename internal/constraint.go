@@ -0,0 +1,262 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tableKey is a UNIQUE or PRIMARY KEY constraint, no two rows having the
+// same values in its columns. Rows with a NULL in any of them are exempt.
+type tableKey struct {
+	columns []int
+	primary bool
+}
+
+// addColumnConstraints adds the constraints declared along with the last
+// column of the table
+func (mb *MemoryBackend) addColumnConstraints(t *table, col *columnDefinition) error {
+	i := len(t.columns) - 1
+	t.notNull = append(t.notNull, col.notNull)
+
+	if col.unique {
+		t.keys = append(t.keys, &tableKey{columns: []int{i}})
+	}
+	if col.primaryKey {
+		if err := addPrimaryKey(t, []int{i}); err != nil {
+			return err
+		}
+	}
+	if col.check != nil {
+		t.checks = append(t.checks, col.check)
+	}
+	return nil
+}
+
+// addConstraint adds a table constraint to the table
+func (mb *MemoryBackend) addConstraint(t *table, constraint *tableConstraint) error {
+	if constraint.kind == checkConstraintKind {
+		t.checks = append(t.checks, constraint.check)
+		return nil
+	}
+
+	var columns []int
+	for _, name := range constraint.columns {
+		i := t.columnIndex(name.value)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrColumnDoesNotExist, name.value)
+		}
+		for _, column := range columns {
+			if column == i {
+				return fmt.Errorf("%w: %s", ErrDuplicateColumnName, name.value)
+			}
+		}
+		columns = append(columns, i)
+	}
+
+	if constraint.kind == primaryKeyConstraintKind {
+		return addPrimaryKey(t, columns)
+	}
+	t.keys = append(t.keys, &tableKey{columns: columns})
+	return nil
+}
+
+// addPrimaryKey adds the primary key of the table, whose columns cannot be
+// NULL
+func addPrimaryKey(t *table, columns []int) error {
+	for _, key := range t.keys {
+		if key.primary {
+			return ErrMultiplePrimaryKeys
+		}
+	}
+
+	for _, i := range columns {
+		t.notNull[i] = true
+	}
+	t.keys = append(t.keys, &tableKey{columns: columns, primary: true})
+	return nil
+}
+
+// checkConstraints checks that the CHECK constraints of the table name are
+// boolean expressions over its columns, without aggregates, so that rows can
+// satisfy them, and without subqueries, so that they only read the row
+func (mb *MemoryBackend) checkConstraints(name string, t *table) error {
+	sc := &scope{relation: &relation{columns: tableRelationColumns(name, t)}}
+	for _, check := range t.checks {
+		if hasAggregate(check) {
+			return fmt.Errorf("%w: %s", ErrInvalidAggregate, check.generateCode())
+		}
+		if hasSubquery(check) {
+			return fmt.Errorf("%w: %s", ErrInvalidSubquery, check.generateCode())
+		}
+		if err := mb.checkBoolean(sc, check); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRow checks a new or updated row of the table against its NOT NULL and
+// CHECK constraints. A CHECK is only violated when false, not when unknown.
+func (mb *MemoryBackend) checkRow(name string, t *table, row []MemoryCell) error {
+	for i, notNull := range t.notNull {
+		if notNull && row[i] == nil {
+			return fmt.Errorf("%w: %s.%s", ErrNotNullViolation, name, t.columns[i])
+		}
+	}
+
+	if len(t.checks) == 0 {
+		return nil
+	}
+	sc := &scope{
+		relation: &relation{columns: tableRelationColumns(name, t)},
+		row:      row,
+	}
+	for _, check := range t.checks {
+		result, err := mb.evaluateTruth(sc, check)
+		if err != nil {
+			return err
+		}
+		if result == truthFalse {
+			return fmt.Errorf("%w: %s", ErrCheckViolation, check.generateCode())
+		}
+	}
+	return nil
+}
+
+// checkKeys checks that the rows, all those the table would hold, satisfy
+// its UNIQUE and PRIMARY KEY constraints
+func checkKeys(t *table, rows [][]MemoryCell) error {
+	for _, key := range t.keys {
+		seen := map[string]bool{}
+	rows:
+		for _, row := range rows {
+			var k []byte
+			for _, i := range key.columns {
+				if row[i] == nil {
+					continue rows
+				}
+				k = appendGroupKey(k, row[i], t.columnTypes[i])
+			}
+
+			if seen[string(k)] {
+				var columns, values []string
+				for _, i := range key.columns {
+					columns = append(columns, t.columns[i])
+					values = append(values, cellText(row[i], t.columnTypes[i]))
+				}
+				return fmt.Errorf("%w: (%s)=(%s)", ErrUniqueViolation, strings.Join(columns, ", "), strings.Join(values, ", "))
+			}
+			seen[string(k)] = true
+		}
+	}
+	return nil
+}
+
+// dropColumnConstraints removes the constraints involving the column at i
+// from the table, renumbering the columns of the others
+func dropColumnConstraints(t *table, i int) {
+	t.notNull = append(append([]bool{}, t.notNull[:i]...), t.notNull[i+1:]...)
+
+	var keys []*tableKey
+keys:
+	for _, key := range t.keys {
+		var columns []int
+		for _, column := range key.columns {
+			if column == i {
+				continue keys
+			}
+			if column > i {
+				column--
+			}
+			columns = append(columns, column)
+		}
+		keys = append(keys, &tableKey{columns: columns, primary: key.primary})
+	}
+	t.keys = keys
+
+	var checks []*expression
+	for _, check := range t.checks {
+		reads := false
+		walkColumnRefs(check, func(ref *expression) {
+			if ref.literal.value == t.columns[i] {
+				reads = true
+			}
+		})
+		if !reads {
+			checks = append(checks, check)
+		}
+	}
+	t.checks = checks
+}
+
+// hasSubquery reports whether the expression holds a subquery
+func hasSubquery(exp *expression) bool {
+	switch exp.kind {
+	case subqueryKind, existsKind:
+		return true
+	case inKind:
+		if exp.in.subquery != nil || hasSubquery(exp.in.operand) {
+			return true
+		}
+		for _, e := range exp.in.list {
+			if hasSubquery(e) {
+				return true
+			}
+		}
+	case isNullKind:
+		return hasSubquery(exp.isNull.operand)
+	case unaryKind:
+		return hasSubquery(exp.unary.operand)
+	case binaryKind:
+		return hasSubquery(exp.binary.a) || hasSubquery(exp.binary.b)
+	case functionKind:
+		for _, arg := range exp.function.args {
+			if hasSubquery(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// walkColumnRefs calls fn with every column reference of the expression,
+// which holds no subquery
+func walkColumnRefs(exp *expression, fn func(ref *expression)) {
+	switch exp.kind {
+	case literalKind:
+		if exp.literal.kind == IDENTIFIER {
+			fn(exp)
+		}
+	case unaryKind:
+		walkColumnRefs(exp.unary.operand, fn)
+	case binaryKind:
+		walkColumnRefs(exp.binary.a, fn)
+		walkColumnRefs(exp.binary.b, fn)
+	case functionKind:
+		for _, arg := range exp.function.args {
+			walkColumnRefs(arg, fn)
+		}
+	case inKind:
+		walkColumnRefs(exp.in.operand, fn)
+		for _, e := range exp.in.list {
+			walkColumnRefs(e, fn)
+		}
+	case isNullKind:
+		walkColumnRefs(exp.isNull.operand, fn)
+	}
+}
+
+// renameConstraintRefs renames the references to a column, or the
+// qualifiers naming a table, in the CHECK constraints of the table
+func renameConstraintRefs(t *table, qualifier bool, name, newName string) {
+	for _, check := range t.checks {
+		walkColumnRefs(check, func(ref *expression) {
+			if qualifier && ref.qualifier != nil && ref.qualifier.value == name {
+				ref.qualifier = &token{value: newName, kind: IDENTIFIER}
+			}
+			if !qualifier && ref.literal.value == name {
+				ref.literal = &token{value: newName, kind: IDENTIFIER}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package godb
+
+import "testing"
+
+func TestConstraints(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE t (id int PRIMARY KEY, email text UNIQUE, n int NOT NULL DEFAULT 0 CHECK (n >= 0));"},
+		{sql: "CREATE TABLE bad (a int PRIMARY KEY, b int PRIMARY KEY);", err: ErrMultiplePrimaryKeys},
+		{sql: "CREATE TABLE bad (a int PRIMARY KEY, b int, PRIMARY KEY (b));", err: ErrMultiplePrimaryKeys},
+		{sql: "CREATE TABLE bad (a int, UNIQUE (a, b));", err: ErrColumnDoesNotExist},
+		{sql: "CREATE TABLE bad (a int, UNIQUE (a, a));", err: ErrDuplicateColumnName},
+		{sql: "INSERT INTO t (id, email) VALUES (1, 'a'), (2, NULL), (3, NULL);"},
+		{sql: "INSERT INTO t (id, email) VALUES (4, 'a');", err: ErrUniqueViolation},
+		{sql: "INSERT INTO t (id, email) VALUES (4, 'b'), (5, 'b');", err: ErrUniqueViolation},
+		{sql: "INSERT INTO t (id) VALUES (NULL);", err: ErrNotNullViolation},
+		{sql: "INSERT INTO t (id, n) VALUES (4, NULL);", err: ErrNotNullViolation},
+		{sql: "INSERT INTO t (id, n) VALUES (4, -1);", err: ErrCheckViolation},
+		// unknown is not a violation
+		{sql: "UPDATE t SET n = n + 1 WHERE email IS NULL;"},
+		{sql: "SELECT id, n FROM t ORDER BY id;", rows: []string{"1, 0", "2, 1", "3, 1"}},
+	})
+}
+
+func TestTableConstraints(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE seats (row int, seat int, holder text, PRIMARY KEY (row, seat), UNIQUE (holder), CHECK (seat <= row * 2));"},
+		{sql: "INSERT INTO seats VALUES (1, 1, 'ann'), (1, 2, 'bob'), (2, 1, NULL), (2, 2, NULL);"},
+		{sql: "INSERT INTO seats VALUES (1, 3, 'cid');", err: ErrCheckViolation},
+		{sql: "INSERT INTO seats VALUES (2, 1, 'cid');", err: ErrUniqueViolation},
+		{sql: "INSERT INTO seats VALUES (2, NULL, 'cid');", err: ErrNotNullViolation},
+		{sql: "UPDATE seats SET holder = 'ann' WHERE row = 2 AND seat = 2;", err: ErrUniqueViolation},
+		{sql: "UPDATE seats SET seat = seat + 2 WHERE row = 2;"},
+		{sql: "SELECT row, seat FROM seats ORDER BY row, seat;", rows: []string{"1, 1", "1, 2", "2, 3", "2, 4"}},
+	})
+}
+
+func TestAlterTableConstraints(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE t (a int, b int, CHECK (a < b), UNIQUE (a, b));"},
+		{sql: "INSERT INTO t VALUES (1, 2), (1, 3);"},
+
+		// added columns must hold for the rows already there
+		{sql: "ALTER TABLE t ADD c int NOT NULL;", err: ErrNotNullViolation},
+		{sql: "ALTER TABLE t ADD c int UNIQUE DEFAULT 5;", err: ErrUniqueViolation},
+		{sql: "ALTER TABLE t ADD c int DEFAULT 5 CHECK (c < b);", err: ErrCheckViolation},
+		{sql: "ALTER TABLE t ADD c int NOT NULL DEFAULT 5 CHECK (c > a);"},
+		{sql: "SELECT * FROM t;", rows: []string{"1, 2, 5", "1, 3, 5"}},
+
+		// renamed columns are renamed in the CHECK constraints reading them
+		{sql: "ALTER TABLE t RENAME b TO hi;"},
+		{sql: "INSERT INTO t (a, hi) VALUES (4, 3);", err: ErrCheckViolation},
+
+		// dropping a column drops the constraints involving it
+		{sql: "ALTER TABLE t DROP hi;"},
+		{sql: "INSERT INTO t (a) VALUES (1), (1);"},
+		{sql: "INSERT INTO t (a, c) VALUES (9, 1);", err: ErrCheckViolation},
+		{sql: "INSERT INTO t (a, c) VALUES (0, NULL);", err: ErrNotNullViolation},
+	})
+}
+
+func TestDropColumnConstraints(t *testing.T) {
+	tbl := &table{
+		columns: []string{"a", "b", "c"},
+		notNull: []bool{true, false, true},
+		keys: []*tableKey{
+			{columns: []int{0, 2}, primary: true},
+			{columns: []int{1}},
+			{columns: []int{2}},
+		},
+	}
+	dropColumnConstraints(tbl, 1)
+
+	// the keys over the other columns are kept, renumbered
+	if len(tbl.notNull) != 2 || !tbl.notNull[0] || !tbl.notNull[1] {
+		t.Errorf("got NOT NULL %v, want [true true]", tbl.notNull)
+	}
+	if len(tbl.keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(tbl.keys))
+	}
+	if key := tbl.keys[0]; !key.primary || len(key.columns) != 2 || key.columns[0] != 0 || key.columns[1] != 1 {
+		t.Errorf("got primary key %v, want columns [0 1]", key.columns)
+	}
+	if key := tbl.keys[1]; key.primary || len(key.columns) != 1 || key.columns[0] != 1 {
+		t.Errorf("got unique key %v, want columns [1]", key.columns)
+	}
+}
+
+func TestStatementAtomicity(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE t (id int PRIMARY KEY, v int CHECK (v < 10));"},
+		{sql: "INSERT INTO t VALUES (1, 1), (2, 2);"},
+
+		// no row of a failing statement is written
+		{sql: "INSERT INTO t VALUES (3, 3), (1, 4);", err: ErrUniqueViolation},
+		{sql: "INSERT INTO t VALUES (3, 3), (4, 40);", err: ErrCheckViolation},
+		{sql: "INSERT INTO t SELECT id + 2, v * 5 FROM t;", err: ErrCheckViolation},
+		{sql: "UPDATE t SET v = v + 8;", err: ErrCheckViolation},
+		{sql: "UPDATE t SET v = 10 / (v - 2);", err: ErrDivisionByZero},
+		{sql: "UPDATE t SET id = 2;", err: ErrUniqueViolation},
+		{sql: "SELECT id, v FROM t ORDER BY id;", rows: []string{"1, 1", "2, 2"}},
+
+		// keys are checked once every row is updated
+		{sql: "UPDATE t SET id = 3 - id;"},
+		{sql: "SELECT id, v FROM t ORDER BY id;", rows: []string{"1, 2", "2, 1"}},
+	})
+}
+
+func TestCheckConstraintDefinition(t *testing.T) {
+	runSQL(t, []sqlTest{
+		// CHECK constraints no row could satisfy are rejected with the table
+		{sql: "CREATE TABLE z (a int CHECK (b > 0));", err: ErrColumnDoesNotExist},
+		{sql: "CREATE TABLE z (a int CHECK (a));", err: ErrInvalidPredicate},
+		{sql: "CREATE TABLE z (a int CHECK (a > 'x'));", err: ErrInvalidCell},
+		{sql: "CREATE TABLE z (a int, CHECK (count(*) > 0));", err: ErrInvalidAggregate},
+		{sql: "SELECT * FROM z;", err: ErrTableDoesNotExist},
+
+		{sql: "CREATE TABLE z (a int CHECK (a > '5'), b text, CHECK (a < 10 OR b IS NOT NULL));"},
+		{sql: "INSERT INTO z VALUES (6, NULL), (20, 'x');"},
+		{sql: "ALTER TABLE z ADD COLUMN c int CHECK (c > d);", err: ErrColumnDoesNotExist},
+		{sql: "ALTER TABLE z ADD COLUMN c int CHECK (c);", err: ErrInvalidPredicate},
+		{sql: "ALTER TABLE z ADD COLUMN c int CHECK (c > a);"},
+		{sql: "SELECT a, b, c FROM z ORDER BY a;", rows: []string{"6, NULL, NULL", "20, x, NULL"}},
+	})
+}
+
+func TestConstraintSubqueries(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE limits (n int);"},
+		{sql: "INSERT INTO limits VALUES (5);"},
+
+		// CHECK constraints and defaults only read the row, so they cannot
+		// hold subqueries
+		{sql: "CREATE TABLE z (a int CHECK (a < (SELECT n FROM limits)));", err: ErrInvalidSubquery},
+		{sql: "CREATE TABLE z (a int, CHECK (EXISTS (SELECT 1 FROM limits WHERE n = a)));", err: ErrInvalidSubquery},
+		{sql: "CREATE TABLE z (a int CHECK (a IN (SELECT n FROM limits)));", err: ErrInvalidSubquery},
+		{sql: "CREATE TABLE z (a int DEFAULT (SELECT n FROM limits));", err: ErrInvalidSubquery},
+		{sql: "CREATE TABLE z (a int DEFAULT length((SELECT 'x')));", err: ErrInvalidSubquery},
+		{sql: "SELECT * FROM z;", err: ErrTableDoesNotExist},
+
+		{sql: "CREATE TABLE z (a int CHECK (a IN (1, 2)));"},
+		{sql: "ALTER TABLE z ADD b int CHECK (b < (SELECT n FROM limits));", err: ErrInvalidSubquery},
+		{sql: "ALTER TABLE z ADD b int DEFAULT (SELECT n FROM limits);", err: ErrInvalidSubquery},
+		{sql: "ALTER TABLE z ADD b int DEFAULT 1 CHECK (b IS NOT NULL);"},
+		{sql: "ALTER TABLE z RENAME b TO c;"},
+		{sql: "INSERT INTO z VALUES (1, NULL);", err: ErrCheckViolation},
+	})
+}
@@ -0,0 +1,80 @@
+package godb
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		f    float64
+		text string
+	}{
+		{0, "0"},
+		{2.5, "2.5"},
+		{-0.1, "-0.1"},
+		{1e14, "100000000000000"},
+		{1e15, "1e+15"},
+		{1e-5, "1e-05"},
+		{0.0001, "0.0001"},
+		{math.Inf(1), "Infinity"},
+		{math.Inf(-1), "-Infinity"},
+	}
+
+	for _, test := range tests {
+		if text := formatFloat(test.f); text != test.text {
+			t.Errorf("formatFloat(%v) = %s, want %s", test.f, text, test.text)
+		}
+	}
+}
+
+func TestFloats(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE m (name text, v real, w double precision DEFAULT 1);"},
+		{sql: "INSERT INTO m VALUES ('a', 1.5, 2), ('b', 2, 1e-1), ('c', -0.25, '3.5'), ('d', NULL, .5);"},
+		{sql: "INSERT INTO m (name, v) VALUES ('e', 'x');", err: ErrValueTypeMismatch},
+		{sql: "SELECT name, v, w FROM m ORDER BY name;", rows: []string{"a, 1.5, 2", "b, 2, 0.1", "c, -0.25, 3.5", "d, NULL, 0.5"}},
+
+		// integers are promoted in arithmetic and comparisons
+		{sql: "SELECT v + 1, w / 4, 7 / w, -v, v * 3 FROM m WHERE name = 'a';", rows: []string{"2.5, 0.5, 3.5, -1.5, 4.5"}},
+		{sql: "SELECT v / 0 FROM m;", err: ErrDivisionByZero},
+		{sql: "SELECT v % 1 FROM m;", err: ErrInvalidOperands},
+		{sql: "SELECT w || 'x' FROM m WHERE name = 'a';", rows: []string{"2x"}},
+		{sql: "SELECT name FROM m WHERE v = 2 OR v IN (1, 1.5) ORDER BY name;", rows: []string{"a", "b"}},
+		{sql: "SELECT name FROM m WHERE v < w ORDER BY v;", rows: []string{"c", "a"}},
+		{sql: "SELECT name FROM m ORDER BY v + w DESC NULLS LAST;", rows: []string{"a", "c", "b", "d"}},
+
+		// averages are fractional, sums keep the type of their argument
+		{sql: "SELECT sum(v), avg(v), min(w), max(v) FROM m;", rows: []string{"3.25, 1.0833333333333333, 0.1, 2"}},
+		{sql: "SELECT avg(x) FROM (SELECT 1 AS x UNION ALL SELECT 2) AS t;", rows: []string{"1.5"}},
+
+		// zero and negative zero are the same value
+		{sql: "SELECT DISTINCT v * 0 FROM m WHERE v IS NOT NULL;", rows: []string{"0"}},
+		{sql: "SELECT 2 UNION SELECT v FROM m WHERE name = 'b';", rows: []string{"2"}},
+	})
+}
+
+func TestMemoryCellFloat(t *testing.T) {
+	if f, err := floatCell(-1.25).AsFloat(); err != nil || f != -1.25 {
+		t.Errorf("got %v, %v decoding -1.25, want -1.25", f, err)
+	}
+	if _, err := MemoryCell(nil).AsFloat(); !errors.Is(err, ErrNullCell) {
+		t.Errorf("got %v decoding NULL as a float, want %v", err, ErrNullCell)
+	}
+}
+
+func TestParseDoublePrecision(t *testing.T) {
+	ast, err := parse("CREATE TABLE m (v double precision, precision real);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols := *ast.Statements[0].CreateStatement.cols
+	if cols[0].datatype.value != "double precision" || cols[1].name.value != "precision" || cols[1].datatype.value != "real" {
+		t.Errorf("got columns %s %s, %s %s", cols[0].name.value, cols[0].datatype.value, cols[1].name.value, cols[1].datatype.value)
+	}
+
+	if _, err := parse("CREATE TABLE m (v double);"); err == nil {
+		t.Error("parsed DOUBLE without PRECISION")
+	}
+}
@@ -0,0 +1,409 @@
+package godb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// tableForeignKey is a FOREIGN KEY constraint, the values of its columns
+// having to match those of the referenced columns in a row of the referenced
+// table. Rows with a NULL in any of the columns are exempt.
+type tableForeignKey struct {
+	columns    []int
+	table      string
+	refColumns []int
+	onDelete   referentialAction
+	onUpdate   referentialAction
+	// types are those of the columns, and so of the referenced columns
+	types []ColumnType
+}
+
+// addForeignKey adds a foreign key to the table name, which must reference a
+// UNIQUE or PRIMARY KEY constraint of the referenced table, possibly itself
+func (mb *MemoryBackend) addForeignKey(name string, t *table, columns []token, ref *references) error {
+	fk := tableForeignKey{
+		table:    ref.table.value,
+		onDelete: ref.onDelete,
+		onUpdate: ref.onUpdate,
+	}
+
+	for _, column := range columns {
+		i := t.columnIndex(column.value)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrColumnDoesNotExist, column.value)
+		}
+		fk.columns = append(fk.columns, i)
+		fk.types = append(fk.types, t.columnTypes[i])
+	}
+
+	parent := t
+	if ref.table.value != name {
+		p, ok := mb.tables[ref.table.value]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrTableDoesNotExist, ref.table.value)
+		}
+		parent = p
+	}
+
+	if len(ref.columns) == 0 {
+		for _, key := range parent.keys {
+			if key.primary {
+				fk.refColumns = append([]int{}, key.columns...)
+			}
+		}
+		if fk.refColumns == nil {
+			return fmt.Errorf("%w: %s has no primary key", ErrInvalidForeignKey, ref.table.value)
+		}
+	}
+	for _, column := range ref.columns {
+		i := parent.columnIndex(column.value)
+		if i < 0 {
+			return fmt.Errorf("%w: %s.%s", ErrColumnDoesNotExist, ref.table.value, column.value)
+		}
+		fk.refColumns = append(fk.refColumns, i)
+	}
+
+	if len(fk.columns) != len(fk.refColumns) {
+		return fmt.Errorf("%w: %d columns reference %d", ErrInvalidForeignKey, len(fk.columns), len(fk.refColumns))
+	}
+	for i, column := range fk.columns {
+		if t.columnTypes[column] != parent.columnTypes[fk.refColumns[i]] {
+			return fmt.Errorf("%w: %s and %s.%s have different types", ErrInvalidForeignKey, t.columns[column], ref.table.value, parent.columns[fk.refColumns[i]])
+		}
+	}
+
+	if !isKey(parent, fk.refColumns) {
+		return fmt.Errorf("%w: no unique constraint on the referenced columns of %s", ErrInvalidForeignKey, ref.table.value)
+	}
+
+	t.foreignKeys = append(t.foreignKeys, &fk)
+	return nil
+}
+
+// isKey reports whether the columns, in any order, are those of a UNIQUE or
+// PRIMARY KEY constraint of the table
+func isKey(t *table, columns []int) bool {
+keys:
+	for _, key := range t.keys {
+		if len(key.columns) != len(columns) {
+			continue
+		}
+		for _, column := range columns {
+			found := false
+			for _, k := range key.columns {
+				found = found || k == column
+			}
+			if !found {
+				continue keys
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// foreignKeyValue encodes the values of the columns of a row, of the types
+// of the key, false if any of them is NULL
+func foreignKeyValue(row []MemoryCell, columns []int, types []ColumnType) (string, bool) {
+	var key []byte
+	for j, i := range columns {
+		if row[i] == nil {
+			return "", false
+		}
+		key = appendGroupKey(key, row[i], types[j])
+	}
+	return string(key), true
+}
+
+// rowChange is a row of a table replaced by a statement, new being nil if
+// the row was deleted
+type rowChange struct {
+	old []MemoryCell
+	new []MemoryCell
+}
+
+// changeSet holds the rows tables would hold once a statement completes, so
+// that changes cascading to other tables are applied all at once or not at
+// all. changed holds the new or updated rows of each table, and definitions
+// the tables whose definition is being altered.
+type changeSet struct {
+	rows        map[string][][]MemoryCell
+	changed     map[string][][]MemoryCell
+	definitions map[string]*table
+}
+
+func newChangeSet() *changeSet {
+	return &changeSet{
+		rows:        map[string][][]MemoryCell{},
+		changed:     map[string][][]MemoryCell{},
+		definitions: map[string]*table{},
+	}
+}
+
+// changedTable returns the definition of the named table
+func (mb *MemoryBackend) changedTable(cs *changeSet, name string) *table {
+	if t, ok := cs.definitions[name]; ok {
+		return t
+	}
+	return mb.tables[name]
+}
+
+// changedRows returns the rows the named table would hold
+func (mb *MemoryBackend) changedRows(cs *changeSet, name string) [][]MemoryCell {
+	if rows, ok := cs.rows[name]; ok {
+		return rows
+	}
+	return mb.tables[name].rows
+}
+
+// sortedTableNames returns the names of the tables in a deterministic order
+func sortedTableNames(tables map[string]*table) []string {
+	var names []string
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// propagate applies the ON DELETE and ON UPDATE actions of the foreign keys
+// referencing the table name to the rows referencing the changed rows,
+// recursively as those are changed in turn
+func (mb *MemoryBackend) propagate(cs *changeSet, name string, changes []rowChange) error {
+	for _, childName := range sortedTableNames(mb.tables) {
+		child := mb.tables[childName]
+		for _, fk := range child.foreignKeys {
+			if fk.table != name {
+				continue
+			}
+
+			// the changes by the value of the referenced key they remove
+			removed := map[string]*rowChange{}
+			for i, change := range changes {
+				old, ok := foreignKeyValue(change.old, fk.refColumns, fk.types)
+				if !ok {
+					continue
+				}
+				if change.new != nil {
+					if value, ok := foreignKeyValue(change.new, fk.refColumns, fk.types); ok && value == old {
+						continue
+					}
+				}
+				removed[old] = &changes[i]
+			}
+
+			// a key removed from one row may be given to another
+			for _, row := range mb.changedRows(cs, name) {
+				if value, ok := foreignKeyValue(row, fk.refColumns, fk.types); ok {
+					delete(removed, value)
+				}
+			}
+			if len(removed) == 0 {
+				continue
+			}
+
+			var rows [][]MemoryCell
+			var childChanges []rowChange
+			for _, row := range mb.changedRows(cs, childName) {
+				value, ok := foreignKeyValue(row, fk.columns, fk.types)
+				change, found := removed[value]
+				if !ok || !found {
+					rows = append(rows, row)
+					continue
+				}
+
+				action := fk.onUpdate
+				if change.new == nil {
+					action = fk.onDelete
+				}
+
+				updated := append([]MemoryCell{}, row...)
+				switch action {
+				case restrictAction:
+					return fmt.Errorf("%w: %s", ErrForeignKeyReferenced, describeForeignKey(child, childName, fk, row))
+				case cascadeAction:
+					if change.new == nil {
+						childChanges = append(childChanges, rowChange{old: row})
+						continue
+					}
+					for i, column := range fk.columns {
+						c, err := fitNumeric(childName, child, column, change.new[fk.refColumns[i]])
+						if err != nil {
+							return err
+						}
+						updated[column] = c
+					}
+				case setNullAction:
+					for _, column := range fk.columns {
+						updated[column] = nil
+					}
+				case setDefaultAction:
+					for _, column := range fk.columns {
+						c, err := mb.columnDefault(childName, child, column)
+						if err != nil {
+							return err
+						}
+						updated[column] = c
+					}
+				}
+
+				rows = append(rows, updated)
+				childChanges = append(childChanges, rowChange{old: row, new: updated})
+				cs.changed[childName] = append(cs.changed[childName], updated)
+			}
+
+			cs.rows[childName] = rows
+			if err := mb.propagate(cs, childName, childChanges); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// describeForeignKey renders the values of the columns of a foreign key of a
+// row for error messages
+func describeForeignKey(t *table, name string, fk *tableForeignKey, row []MemoryCell) string {
+	var columns, values []string
+	for _, i := range fk.columns {
+		columns = append(columns, t.columns[i])
+		values = append(values, cellText(row[i], t.columnTypes[i]))
+	}
+	return fmt.Sprintf("%s(%s)=(%s) references %s", name, strings.Join(columns, ", "), strings.Join(values, ", "), fk.table)
+}
+
+// checkReferences checks that the rows of the table name have a matching row
+// in the tables their foreign keys reference
+func (mb *MemoryBackend) checkReferences(cs *changeSet, name string, t *table, rows [][]MemoryCell) error {
+	for _, fk := range t.foreignKeys {
+		keys := map[string]bool{}
+		for _, row := range mb.changedRows(cs, fk.table) {
+			if value, ok := foreignKeyValue(row, fk.refColumns, fk.types); ok {
+				keys[value] = true
+			}
+		}
+
+		for _, row := range rows {
+			value, ok := foreignKeyValue(row, fk.columns, fk.types)
+			if ok && !keys[value] {
+				return fmt.Errorf("%w: %s", ErrForeignKeyViolation, describeForeignKey(t, name, fk, row))
+			}
+		}
+	}
+	return nil
+}
+
+// commit checks the rows of every table of the change set against their
+// constraints, only then replacing the rows of the tables
+func (mb *MemoryBackend) commit(cs *changeSet) error {
+	var names []string
+	for name := range cs.rows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		t := mb.changedTable(cs, name)
+		rows := cs.rows[name]
+
+		// rows changed more than once by cascading actions are only checked
+		// as they end up
+		current := map[*MemoryCell]bool{}
+		for _, row := range rows {
+			if len(row) > 0 {
+				current[&row[0]] = true
+			}
+		}
+		var changed [][]MemoryCell
+		for _, row := range cs.changed[name] {
+			if len(row) > 0 && current[&row[0]] {
+				changed = append(changed, row)
+			}
+		}
+
+		for _, row := range changed {
+			if err := mb.checkRow(name, t, row); err != nil {
+				return err
+			}
+		}
+		if err := checkKeys(t, rows); err != nil {
+			return err
+		}
+		if err := mb.checkReferences(cs, name, t, changed); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		mb.changedTable(cs, name).rows = cs.rows[name]
+	}
+	return nil
+}
+
+// dropColumnForeignKeys removes the foreign keys of the table name involving
+// its column at i, renumbering the columns of the others. Columns referenced
+// by foreign keys cannot be dropped.
+func (mb *MemoryBackend) dropColumnForeignKeys(name string, t *table, i int) error {
+	for _, childName := range sortedTableNames(mb.tables) {
+		for _, fk := range mb.tables[childName].foreignKeys {
+			if fk.table != name {
+				continue
+			}
+			for _, column := range fk.refColumns {
+				if column == i {
+					return fmt.Errorf("%w: %s.%s is referenced by %s", ErrColumnReferenced, name, t.columns[i], childName)
+				}
+			}
+		}
+	}
+
+	var foreignKeys []*tableForeignKey
+fks:
+	for _, fk := range t.foreignKeys {
+		for _, column := range fk.columns {
+			if column == i {
+				continue fks
+			}
+		}
+		foreignKeys = append(foreignKeys, fk)
+	}
+	t.foreignKeys = foreignKeys
+
+	renumber := func(columns []int) {
+		for j := range columns {
+			if columns[j] > i {
+				columns[j]--
+			}
+		}
+	}
+	for _, fk := range t.foreignKeys {
+		renumber(fk.columns)
+	}
+	for _, childName := range sortedTableNames(mb.tables) {
+		for _, fk := range mb.tables[childName].foreignKeys {
+			if fk.table == name {
+				renumber(fk.refColumns)
+			}
+		}
+	}
+	return nil
+}
+
+// referencingTables returns the names of the tables other than name with a
+// foreign key referencing the table name
+func (mb *MemoryBackend) referencingTables(name string) []string {
+	var names []string
+	for _, childName := range sortedTableNames(mb.tables) {
+		if childName == name {
+			continue
+		}
+		for _, fk := range mb.tables[childName].foreignKeys {
+			if fk.table == name {
+				names = append(names, childName)
+				break
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,94 @@
+package godb
+
+import "testing"
+
+func TestForeignKeyRestrict(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE parent (id int PRIMARY KEY, code text UNIQUE);"},
+		{sql: "CREATE TABLE child (id int, parent_id int REFERENCES parent);"},
+		{sql: "CREATE TABLE bad (p text REFERENCES parent (id));", err: ErrInvalidForeignKey},
+		{sql: "CREATE TABLE bad (p int REFERENCES parent (id, code));", err: ErrInvalidForeignKey},
+		{sql: "CREATE TABLE bad (p int REFERENCES missing);", err: ErrTableDoesNotExist},
+
+		{sql: "INSERT INTO parent VALUES (1, 'a'), (2, 'b');"},
+		{sql: "INSERT INTO child VALUES (1, 1), (2, NULL);"},
+		{sql: "INSERT INTO child VALUES (3, 2), (4, 3);", err: ErrForeignKeyViolation},
+		{sql: "UPDATE child SET parent_id = 5 WHERE id = 1;", err: ErrForeignKeyViolation},
+
+		// referenced rows can be neither deleted nor have their key changed
+		{sql: "DELETE FROM parent WHERE id = 1;", err: ErrForeignKeyReferenced},
+		{sql: "UPDATE parent SET id = 10 WHERE id = 1;", err: ErrForeignKeyReferenced},
+		{sql: "UPDATE parent SET code = 'z' WHERE id = 1;"},
+		{sql: "DELETE FROM parent WHERE id = 2;"},
+		{sql: "DROP TABLE parent;", err: ErrTableReferenced},
+		{sql: "SELECT id, code FROM parent;", rows: []string{"1, z"}},
+		{sql: "SELECT id, parent_id FROM child ORDER BY id;", rows: []string{"1, 1", "2, NULL"}},
+
+		// a key swapped between rows in one statement is still referenced
+		{sql: "INSERT INTO parent VALUES (2, 'b');"},
+		{sql: "UPDATE parent SET id = 3 - id;"},
+		{sql: "DROP TABLE child, parent;"},
+	})
+}
+
+func TestForeignKeyActions(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE parent (id int PRIMARY KEY);"},
+		{sql: "CREATE TABLE cascading (id int, p int REFERENCES parent ON DELETE CASCADE ON UPDATE CASCADE);"},
+		{sql: "CREATE TABLE nulling (id int, p int REFERENCES parent ON DELETE SET NULL ON UPDATE SET NULL);"},
+		{sql: "CREATE TABLE defaulting (id int, p int DEFAULT 0 REFERENCES parent ON DELETE SET DEFAULT);"},
+		{sql: "INSERT INTO parent VALUES (0), (1), (2);"},
+		{sql: "INSERT INTO cascading VALUES (1, 1), (2, 2);"},
+		{sql: "INSERT INTO nulling VALUES (1, 1), (2, 2);"},
+		{sql: "INSERT INTO defaulting VALUES (1, 2), (2, 2);"},
+
+		{sql: "UPDATE parent SET id = 10 WHERE id = 1;"},
+		{sql: "SELECT id, p FROM cascading ORDER BY id;", rows: []string{"1, 10", "2, 2"}},
+		{sql: "SELECT id, p FROM nulling ORDER BY id;", rows: []string{"1, NULL", "2, 2"}},
+
+		// defaulting has no ON UPDATE action, so the key cannot change and
+		// the statement changes no row of any table
+		{sql: "UPDATE parent SET id = 20 WHERE id = 2;", err: ErrForeignKeyReferenced},
+		{sql: "SELECT id, p FROM cascading ORDER BY id;", rows: []string{"1, 10", "2, 2"}},
+		{sql: "SELECT id, p FROM nulling ORDER BY id;", rows: []string{"1, NULL", "2, 2"}},
+
+		{sql: "DELETE FROM parent WHERE id = 2;"},
+		{sql: "SELECT id, p FROM cascading ORDER BY id;", rows: []string{"1, 10"}},
+		{sql: "SELECT id, p FROM nulling ORDER BY id;", rows: []string{"1, NULL", "2, NULL"}},
+		{sql: "SELECT id, p FROM defaulting ORDER BY id;", rows: []string{"1, 0", "2, 0"}},
+
+		// the default must itself be referenced
+		{sql: "DELETE FROM parent WHERE id = 0;", err: ErrForeignKeyViolation},
+		{sql: "SELECT count(*) FROM parent WHERE id = 0;", rows: []string{"1"}},
+		{sql: "DELETE FROM defaulting;"},
+		{sql: "DELETE FROM parent WHERE id = 0;"},
+		{sql: "DELETE FROM parent WHERE id = 10;"},
+		{sql: "SELECT count(*) FROM cascading;", rows: []string{"0"}},
+	})
+}
+
+func TestForeignKeySelfReference(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE node (id int PRIMARY KEY, parent int REFERENCES node ON DELETE CASCADE);"},
+		{sql: "INSERT INTO node VALUES (1, NULL), (2, 1), (3, 2), (4, NULL);"},
+		{sql: "INSERT INTO node VALUES (5, 6), (6, 5);"},
+		{sql: "INSERT INTO node VALUES (7, 8);", err: ErrForeignKeyViolation},
+
+		// deletes cascade down the tree
+		{sql: "DELETE FROM node WHERE id = 1;"},
+		{sql: "SELECT id FROM node ORDER BY id;", rows: []string{"4", "5", "6"}},
+	})
+}
+
+func TestForeignKeyComposite(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE parent (a int, b int, PRIMARY KEY (a, b));"},
+		{sql: "CREATE TABLE child (a int, b int, FOREIGN KEY (a, b) REFERENCES parent ON UPDATE CASCADE);"},
+		{sql: "INSERT INTO parent VALUES (1, 1), (1, 2);"},
+		{sql: "INSERT INTO child VALUES (1, 2), (2, NULL);"},
+		{sql: "INSERT INTO child VALUES (2, 1);", err: ErrForeignKeyViolation},
+		{sql: "UPDATE parent SET b = 3 WHERE b = 2;"},
+		{sql: "SELECT a, b FROM child ORDER BY a;", rows: []string{"1, 3", "2, NULL"}},
+		{sql: "ALTER TABLE parent DROP COLUMN b;", err: ErrColumnReferenced},
+	})
+}
@@ -0,0 +1,211 @@
+package godb
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// scalarFunction computes a value from the values of its arguments, NULL if
+// any of them is NULL
+type scalarFunction struct {
+	// returns checks the types of the arguments, returning the type of the
+	// result
+	returns func(args []ColumnType) (ColumnType, error)
+	call    func(args []MemoryCell, types []ColumnType) (MemoryCell, error)
+}
+
+var scalarFunctions = map[string]*scalarFunction{
+	"now": {
+		returns: func(args []ColumnType) (ColumnType, error) {
+			if len(args) != 0 {
+				return 0, fmt.Errorf("%w: now takes no arguments", ErrInvalidArguments)
+			}
+			return TimestampTzType, nil
+		},
+		call: func([]MemoryCell, []ColumnType) (MemoryCell, error) {
+			return intCell(timeUsec(time.Now())), nil
+		},
+	},
+	"date_trunc": {
+		returns: func(args []ColumnType) (ColumnType, error) {
+			if len(args) != 2 || !isFieldType(args[0]) {
+				return 0, fmt.Errorf("%w: date_trunc takes a field and a timestamp", ErrInvalidArguments)
+			}
+			switch args[1] {
+			case TimestampType, TimestampTzType:
+				return args[1], nil
+			case DateType, nullType:
+				return TimestampType, nil
+			}
+			return 0, fmt.Errorf("%w: date_trunc of a %s", ErrInvalidArguments, args[1])
+		},
+		call: func(args []MemoryCell, types []ColumnType) (MemoryCell, error) {
+			t, err := truncateTime(cellTime(args[1], types[1]), fieldName(args[0]))
+			if err != nil {
+				return nil, err
+			}
+			return timestampResult(t)
+		},
+	},
+	"date_part": datePart,
+	"extract":   datePart,
+	// lengths and positions count the characters of text, but the bytes of
+	// binary strings
+	"length": {
+		returns: func(args []ColumnType) (ColumnType, error) {
+			if len(args) != 1 || !isStringType(args[0]) {
+				return 0, fmt.Errorf("%w: length takes a text or bytea", ErrInvalidArguments)
+			}
+			return IntType, nil
+		},
+		call: func(args []MemoryCell, types []ColumnType) (MemoryCell, error) {
+			if types[0] == BlobType {
+				return intCell(int64(len(args[0]))), nil
+			}
+			return intCell(int64(utf8.RuneCount(args[0]))), nil
+		},
+	},
+	"substr": {
+		returns: func(args []ColumnType) (ColumnType, error) {
+			if len(args) < 2 || len(args) > 3 || !isStringType(args[0]) || !isIntType(args[1]) || (len(args) == 3 && !isIntType(args[2])) {
+				return 0, fmt.Errorf("%w: substr takes a text or bytea, a start and optionally a length", ErrInvalidArguments)
+			}
+			if args[0] == BlobType {
+				return BlobType, nil
+			}
+			return TextType, nil
+		},
+		call: func(args []MemoryCell, types []ColumnType) (MemoryCell, error) {
+			count := int64(-1)
+			if len(args) == 3 {
+				if count = args[2].int(); count < 0 {
+					return nil, fmt.Errorf("%w: negative substring length", ErrInvalidArguments)
+				}
+			}
+			if types[0] == BlobType {
+				from, to := substringBounds(len(args[0]), args[1].int(), count)
+				return blobCell(args[0][from:to]), nil
+			}
+			s := []rune(string(args[0]))
+			from, to := substringBounds(len(s), args[1].int(), count)
+			return textCell(string(s[from:to])), nil
+		},
+	},
+}
+
+// datePart returns a field of a date, time, timestamp or interval, as
+// date_part(field, source) or EXTRACT(field FROM source)
+var datePart = &scalarFunction{
+	returns: func(args []ColumnType) (ColumnType, error) {
+		if len(args) != 2 || !isFieldType(args[0]) || (!isTemporalType(args[1]) && args[1] != nullType) {
+			return 0, fmt.Errorf("%w: extract takes a field and a date, time or interval", ErrInvalidArguments)
+		}
+		return FloatType, nil
+	},
+	call: func(args []MemoryCell, types []ColumnType) (MemoryCell, error) {
+		var f float64
+		var err error
+		if types[1] == IntervalType {
+			f, err = extractIntervalField(args[1].interval(), fieldName(args[0]))
+		} else {
+			f, err = extractField(cellTime(args[1], types[1]), types[1], fieldName(args[0]))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return floatCell(f), nil
+	},
+}
+
+// isStringType reports whether values of the type are text or binary
+// strings
+func isStringType(typ ColumnType) bool {
+	return typ == TextType || isBlobType(typ)
+}
+
+// substringBounds returns the indexes delimiting the count units, all if
+// count is negative, of a string of n units from the 1-based position start.
+// Positions before the first take up the count.
+func substringBounds(n int, start, count int64) (int, int) {
+	end := int64(n) + 1
+	if count >= 0 && (start <= 0 || count <= math.MaxInt64-start) && start+count < end {
+		end = start + count
+	}
+	if start < 1 {
+		start = 1
+	}
+	if start > int64(n)+1 {
+		start = int64(n) + 1
+	}
+	if end < start {
+		end = start
+	}
+	return int(start - 1), int(end - 1)
+}
+
+// isFieldType reports whether values of the type can name a date or time
+// field
+func isFieldType(typ ColumnType) bool {
+	return typ == TextType || typ == nullType
+}
+
+// fieldName normalizes the name of a date or time field
+func fieldName(c MemoryCell) string {
+	return strings.ToLower(strings.TrimSpace(string(c)))
+}
+
+// scalarFunctionType checks the arguments of a call to a scalar function,
+// returning the type of its result
+func (mb *MemoryBackend) scalarFunctionType(sc *scope, fn *functionCall) (ColumnType, error) {
+	f, ok := scalarFunctions[fn.name.value]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrFunctionDoesNotExist, fn.name.value)
+	}
+	if fn.asterisk || fn.distinct {
+		return 0, fmt.Errorf("%w: %s is not an aggregate function", ErrInvalidArguments, fn.name.value)
+	}
+
+	var types []ColumnType
+	for _, arg := range fn.args {
+		typ, err := mb.expressionType(sc, arg)
+		if err != nil {
+			return 0, err
+		}
+		types = append(types, typ)
+	}
+	return f.returns(types)
+}
+
+// evaluateScalarFunction evaluates a call to a scalar function
+func (mb *MemoryBackend) evaluateScalarFunction(sc *scope, fn *functionCall) (MemoryCell, ColumnType, error) {
+	f, ok := scalarFunctions[fn.name.value]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %s", ErrFunctionDoesNotExist, fn.name.value)
+	}
+	if fn.asterisk || fn.distinct {
+		return nil, 0, fmt.Errorf("%w: %s is not an aggregate function", ErrInvalidArguments, fn.name.value)
+	}
+
+	var args []MemoryCell
+	var types []ColumnType
+	null := false
+	for _, arg := range fn.args {
+		c, typ, err := mb.evaluateCell(sc, arg)
+		if err != nil {
+			return nil, 0, err
+		}
+		args = append(args, c)
+		types = append(types, typ)
+		null = null || c == nil
+	}
+
+	typ, err := f.returns(types)
+	if err != nil || null {
+		return nil, typ, err
+	}
+	c, err := f.call(args, types)
+	return c, typ, err
+}
@@ -0,0 +1,456 @@
+package godb
+
+import "fmt"
+
+// relationColumn is a column of the rows a select reads, table being the
+// name or alias it can be qualified with
+type relationColumn struct {
+	table string
+	name  string
+	typ   ColumnType
+	// columns joined USING are only visible qualified, unqualified references
+	// reading the column merging them instead
+	using bool
+}
+
+// relation is the rows a select reads, those of a table or of joined tables
+type relation struct {
+	columns []relationColumn
+	rows    [][]MemoryCell
+}
+
+// columnIndex resolves a column reference, which may be qualified by a table
+// name or alias, to the position of the column
+func (r *relation) columnIndex(qualifier *token, name string) (int, error) {
+	ref := name
+	if qualifier != nil {
+		ref = qualifier.value + "." + name
+	}
+
+	index := -1
+	if r != nil {
+		for i, col := range r.columns {
+			if col.name != name {
+				continue
+			}
+			if qualifier != nil {
+				if col.table != qualifier.value {
+					continue
+				}
+			} else if col.using {
+				continue
+			}
+
+			if index >= 0 {
+				return -1, fmt.Errorf("%w: %s", ErrAmbiguousColumn, ref)
+			}
+			index = i
+		}
+	}
+
+	if index < 0 {
+		return -1, fmt.Errorf("%w: %s", ErrColumnDoesNotExist, ref)
+	}
+	return index, nil
+}
+
+// tables returns the names and aliases qualifying the columns
+func (r *relation) tables() map[string]bool {
+	tables := map[string]bool{}
+	for _, col := range r.columns {
+		if col.table != "" {
+			tables[col.table] = true
+		}
+	}
+	return tables
+}
+
+// tableColumns returns the columns of a table, qualified by its alias if it
+// has one since an aliased table can only be referred to by its alias
+func (mb *MemoryBackend) tableColumns(from *fromItem) (*table, []relationColumn, error) {
+	t, ok := mb.tables[from.table.value]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrTableDoesNotExist, from.table.value)
+	}
+
+	name := from.table.value
+	if from.alias != nil {
+		name = from.alias.value
+	}
+	return t, tableRelationColumns(name, t), nil
+}
+
+// tableRelationColumns returns the columns of a table qualified by name
+func tableRelationColumns(name string, t *table) []relationColumn {
+	var columns []relationColumn
+	for i, col := range t.columns {
+		columns = append(columns, relationColumn{
+			table: name,
+			name:  col,
+			typ:   t.columnTypes[i],
+		})
+	}
+	return columns
+}
+
+// subqueryColumns converts the columns of a derived table, qualified by its
+// alias
+func subqueryColumns(from *fromItem, columns []ResultColumn) []relationColumn {
+	name := ""
+	if from.alias != nil {
+		name = from.alias.value
+	}
+
+	var r []relationColumn
+	for _, col := range columns {
+		r = append(r, relationColumn{
+			table: name,
+			name:  col.Name,
+			typ:   col.Type,
+		})
+	}
+	return r
+}
+
+// relationColumns returns the columns of a FROM item without reading its rows
+func (mb *MemoryBackend) relationColumns(from *fromItem, outer *scope) (*relation, error) {
+	switch from.kind {
+	case tableFromKind:
+		_, columns, err := mb.tableColumns(from)
+		if err != nil {
+			return nil, err
+		}
+		return &relation{columns: columns}, nil
+	case subqueryFromKind:
+		columns, err := mb.describe(from.subquery, outer)
+		if err != nil {
+			return nil, err
+		}
+		return &relation{columns: subqueryColumns(from, columns)}, nil
+	case joinFromKind:
+		left, err := mb.relationColumns(from.join.left, outer)
+		if err != nil {
+			return nil, err
+		}
+		right, err := mb.relationColumns(from.join.right, outer)
+		if err != nil {
+			return nil, err
+		}
+		columns, _, _, err := joinColumns(from.join, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return &relation{columns: columns}, nil
+	}
+	return nil, ErrTableDoesNotExist
+}
+
+// buildRelation reads the rows of a FROM item, derived tables being run in
+// the scope of the enclosing query
+func (mb *MemoryBackend) buildRelation(from *fromItem, outer *scope) (*relation, error) {
+	switch from.kind {
+	case tableFromKind:
+		t, columns, err := mb.tableColumns(from)
+		if err != nil {
+			return nil, err
+		}
+		return &relation{columns: columns, rows: t.rows}, nil
+	case subqueryFromKind:
+		results, err := mb.query(from.subquery, outer)
+		if err != nil {
+			return nil, err
+		}
+
+		r := relation{columns: subqueryColumns(from, results.Columns)}
+		for _, result := range results.Row {
+			row := make([]MemoryCell, len(result))
+			for i, c := range result {
+				row[i] = c.(MemoryCell)
+			}
+			r.rows = append(r.rows, row)
+		}
+		return &r, nil
+	case joinFromKind:
+		left, err := mb.buildRelation(from.join.left, outer)
+		if err != nil {
+			return nil, err
+		}
+		right, err := mb.buildRelation(from.join.right, outer)
+		if err != nil {
+			return nil, err
+		}
+
+		leftTables := left.tables()
+		for table := range right.tables() {
+			if leftTables[table] {
+				return nil, fmt.Errorf("%w: %s", ErrDuplicateTableName, table)
+			}
+		}
+		return mb.join(from.join, left, right, outer)
+	}
+	return nil, ErrTableDoesNotExist
+}
+
+// joinColumns returns the columns of a join and the positions of the columns
+// joined USING on each side. The columns joined USING are merged into one,
+// placed first, of the type both sides are compared as.
+func joinColumns(jc *joinClause, left, right *relation) ([]relationColumn, []int, []int, error) {
+	var columns []relationColumn
+	var usingLeft, usingRight []int
+	leftColumns := append([]relationColumn{}, left.columns...)
+	rightColumns := append([]relationColumn{}, right.columns...)
+	for _, name := range jc.using {
+		l, err := left.columnIndex(nil, name.value)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		r, err := right.columnIndex(nil, name.value)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		typ, err := unifyOperands(left.columns[l].typ, right.columns[r].typ)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%w: %s", err, name.value)
+		}
+
+		usingLeft = append(usingLeft, l)
+		usingRight = append(usingRight, r)
+		leftColumns[l].using = true
+		rightColumns[r].using = true
+		columns = append(columns, relationColumn{
+			name: name.value,
+			typ:  typ,
+		})
+	}
+	columns = append(columns, leftColumns...)
+	columns = append(columns, rightColumns...)
+	return columns, usingLeft, usingRight, nil
+}
+
+// join combines the rows of both sides of a join. Rows are matched with a
+// hash join when the join condition equates the sides, with a nested loop
+// otherwise.
+func (mb *MemoryBackend) join(jc *joinClause, left, right *relation, outer *scope) (*relation, error) {
+	columns, usingLeft, usingRight, err := joinColumns(jc, left, right)
+	if err != nil {
+		return nil, err
+	}
+	joined := relation{columns: columns}
+
+	// the columns joined USING hold whichever side is not NULL
+
+	combine := func(l, r []MemoryCell) []MemoryCell {
+		row := make([]MemoryCell, 0, len(joined.columns))
+		for i := range usingLeft {
+			typ := joined.columns[i].typ
+			var c MemoryCell
+			if l != nil {
+				c = convertCell(l[usingLeft[i]], left.columns[usingLeft[i]].typ, typ)
+			}
+			if c == nil && r != nil {
+				c = convertCell(r[usingRight[i]], right.columns[usingRight[i]].typ, typ)
+			}
+			row = append(row, c)
+		}
+
+		// the missing side of an outer join is padded with NULLs
+		if l == nil {
+			l = make([]MemoryCell, len(left.columns))
+		}
+		if r == nil {
+			r = make([]MemoryCell, len(right.columns))
+		}
+		row = append(row, l...)
+		return append(row, r...)
+	}
+
+	leftKeys, rightKeys, keyTypes, err := mb.equiJoinKeys(jc, left, right)
+	if err != nil {
+		return nil, err
+	}
+
+	// index the right rows by their join key
+	var index map[string][]int
+	if len(leftKeys) > 0 {
+		index = map[string][]int{}
+		for i, row := range right.rows {
+			key, err := mb.joinKey(right, row, rightKeys, keyTypes)
+			if err != nil {
+				return nil, err
+			}
+			if key != nil {
+				index[*key] = append(index[*key], i)
+			}
+		}
+	}
+
+	rightMatched := make([]bool, len(right.rows))
+	for _, l := range left.rows {
+		var candidates []int
+		if index != nil {
+			key, err := mb.joinKey(left, l, leftKeys, keyTypes)
+			if err != nil {
+				return nil, err
+			}
+			if key != nil {
+				candidates = index[*key]
+			}
+		} else {
+			for i := range right.rows {
+				candidates = append(candidates, i)
+			}
+		}
+
+		matched := false
+		for _, i := range candidates {
+			row := combine(l, right.rows[i])
+			if jc.on != nil {
+				ok, err := mb.evaluatePredicate(&scope{relation: &joined, row: row, outer: outer}, jc.on)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+			}
+
+			matched = true
+			rightMatched[i] = true
+			joined.rows = append(joined.rows, row)
+		}
+
+		if !matched && (jc.kind == leftJoinKind || jc.kind == fullJoinKind) {
+			joined.rows = append(joined.rows, combine(l, nil))
+		}
+	}
+
+	if jc.kind == rightJoinKind || jc.kind == fullJoinKind {
+		for i, r := range right.rows {
+			if !rightMatched[i] {
+				joined.rows = append(joined.rows, combine(nil, r))
+			}
+		}
+	}
+	return &joined, nil
+}
+
+// joinKey evaluates the join key expressions against a row, converting
+// them to the key types, returning nil if any of them is NULL since NULLs
+// never match
+func (mb *MemoryBackend) joinKey(r *relation, row []MemoryCell, keys []*expression, types []ColumnType) (*string, error) {
+	var key []byte
+	for i, exp := range keys {
+		c, typ, err := mb.evaluateCell(&scope{relation: r, row: row}, exp)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, nil
+		}
+		key = appendGroupKey(key, convertCell(c, typ, types[i]), types[i])
+	}
+	k := string(key)
+	return &k, nil
+}
+
+// equiJoinKeys finds the expressions over each side that the join condition
+// requires to be equal, and the types they are compared as: the USING
+// columns, or the conjuncts of the ON predicate equating an expression over
+// the left rows with one over the right rows
+func (mb *MemoryBackend) equiJoinKeys(jc *joinClause, left, right *relation) ([]*expression, []*expression, []ColumnType, error) {
+	var leftKeys, rightKeys []*expression
+	var keyTypes []ColumnType
+	for _, name := range jc.using {
+		name := name
+		exp := &expression{literal: &name, kind: literalKind}
+		aType, err := mb.expressionType(&scope{relation: left}, exp)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		bType, err := mb.expressionType(&scope{relation: right}, exp)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		typ, err := unifyOperands(aType, bType)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		leftKeys = append(leftKeys, exp)
+		rightKeys = append(rightKeys, exp)
+		keyTypes = append(keyTypes, typ)
+	}
+	if jc.on == nil {
+		return leftKeys, rightKeys, keyTypes, nil
+	}
+
+	for _, exp := range conjuncts(jc.on) {
+		if exp.kind != binaryKind || !exp.binary.op.equals(&token{value: string(EQUALS), kind: SYMBOL}) {
+			continue
+		}
+
+		a, b := exp.binary.a, exp.binary.b
+		if !readsOnly(a, left, right) || !readsOnly(b, right, left) {
+			a, b = b, a
+			if !readsOnly(a, left, right) || !readsOnly(b, right, left) {
+				continue
+			}
+		}
+
+		// values of different types never compare equal in a hash, the
+		// predicate is left to report the mismatch
+		aType, err := mb.expressionType(&scope{relation: left}, a)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		bType, err := mb.expressionType(&scope{relation: right}, b)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if aType != bType {
+			continue
+		}
+
+		leftKeys = append(leftKeys, a)
+		rightKeys = append(rightKeys, b)
+		keyTypes = append(keyTypes, aType)
+	}
+	return leftKeys, rightKeys, keyTypes, nil
+}
+
+// conjuncts splits a predicate into the operands of its top level ANDs
+func conjuncts(exp *expression) []*expression {
+	if exp.kind == binaryKind && exp.binary.op.equals(&token{value: string(AND), kind: KEYWORD}) {
+		return append(conjuncts(exp.binary.a), conjuncts(exp.binary.b)...)
+	}
+	return []*expression{exp}
+}
+
+// readsOnly reports whether the expression reads at least one column and
+// every column it reads resolves in r but not in other
+func readsOnly(exp *expression, r, other *relation) bool {
+	switch exp.kind {
+	case literalKind:
+		if exp.literal.kind != IDENTIFIER {
+			return false
+		}
+		if _, err := r.columnIndex(exp.qualifier, exp.literal.value); err != nil {
+			return false
+		}
+		_, err := other.columnIndex(exp.qualifier, exp.literal.value)
+		return err != nil
+	case unaryKind:
+		return readsOnly(exp.unary.operand, r, other)
+	case binaryKind:
+		a := exp.binary.a.kind != literalKind || exp.binary.a.literal.kind == IDENTIFIER
+		b := exp.binary.b.kind != literalKind || exp.binary.b.literal.kind == IDENTIFIER
+		if a && !readsOnly(exp.binary.a, r, other) {
+			return false
+		}
+		if b && !readsOnly(exp.binary.b, r, other) {
+			return false
+		}
+		return a || b
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package godb
+
+import (
+	"strings"
+	"testing"
+)
+
+// joinTree renders a FROM item with its joins in parentheses
+func joinTree(from *fromItem) string {
+	if from.kind == tableFromKind {
+		if from.alias != nil {
+			return from.table.value + " " + from.alias.value
+		}
+		return from.table.value
+	}
+	kinds := map[joinKind]string{
+		innerJoinKind: "join",
+		leftJoinKind:  "left join",
+		rightJoinKind: "right join",
+		fullJoinKind:  "full join",
+		crossJoinKind: "cross join",
+	}
+	s := "(" + joinTree(from.join.left) + " " + kinds[from.join.kind] + " " + joinTree(from.join.right)
+	if from.join.on != nil {
+		s += " on " + from.join.on.generateCode()
+	}
+	for _, name := range from.join.using {
+		s += " using " + name.value
+	}
+	return s + ")"
+}
+
+func TestParseJoins(t *testing.T) {
+	tests := []struct {
+		from string
+		want string
+	}{
+		{"a, b", "(a cross join b)"},
+		{"a AS x JOIN b y ON x.id = y.id", "(a x join b y on x.id = y.id)"},
+		{"a INNER JOIN b USING (id, k) LEFT OUTER JOIN c ON b.id = c.id", "((a join b using id using k) left join c on b.id = c.id)"},
+		{"a RIGHT JOIN b ON true_col, c FULL JOIN d USING (id)", "((a right join b on true_col) cross join (c full join d using id))"},
+		{"a CROSS JOIN b CROSS JOIN c", "((a cross join b) cross join c)"},
+	}
+	for _, test := range tests {
+		ast, err := parse("SELECT * FROM " + test.from + ";")
+		if err != nil {
+			t.Errorf("%s: %v", test.from, err)
+			continue
+		}
+		if got := joinTree(ast.Statements[0].SelectStatement.from); got != test.want {
+			t.Errorf("%s: parsed as %s, want %s", test.from, got, test.want)
+		}
+	}
+
+	for _, from := range []string{"a JOIN b", "a CROSS JOIN b ON a.id = b.id", "a JOIN b USING ()", "a LEFT b ON a.id = b.id"} {
+		if _, err := parse("SELECT * FROM " + from + ";"); err == nil {
+			t.Errorf("%s: parsed", from)
+		}
+	}
+}
+
+func TestEquiJoinKeys(t *testing.T) {
+	left := &relation{columns: []relationColumn{{table: "a", name: "id", typ: IntType}, {table: "a", name: "name", typ: TextType}}}
+	right := &relation{columns: []relationColumn{{table: "b", name: "a_id", typ: IntType}, {table: "b", name: "name", typ: TextType}}}
+
+	// only conjuncts equating an expression over each side, of the same
+	// type, are hashed on
+	tests := []struct {
+		on    string
+		left  []string
+		right []string
+	}{
+		{"a.id = b.a_id", []string{"a.id"}, []string{"b.a_id"}},
+		{"b.a_id = a.id + 1 AND a.name = b.name", []string{"a.id + 1", "a.name"}, []string{"b.a_id", "b.name"}},
+		{"a.id = b.a_id OR a.name = b.name", nil, nil},
+		{"a.id < b.a_id AND a.id = 1", nil, nil},
+		{"a.id = a.id AND id = b.a_id", []string{"id"}, []string{"b.a_id"}},
+		{"a.name = b.a_id", nil, nil},
+	}
+	mb := NewMemoryBackend()
+	for _, test := range tests {
+		ast, err := parse("SELECT * FROM a JOIN b ON " + test.on + ";")
+		if err != nil {
+			t.Errorf("%s: %v", test.on, err)
+			continue
+		}
+		leftKeys, rightKeys, _, err := mb.equiJoinKeys(ast.Statements[0].SelectStatement.from.join, left, right)
+		if err != nil {
+			t.Errorf("%s: %v", test.on, err)
+			continue
+		}
+
+		var gotLeft, gotRight []string
+		for i := range leftKeys {
+			gotLeft = append(gotLeft, leftKeys[i].generateCode())
+			gotRight = append(gotRight, rightKeys[i].generateCode())
+		}
+		if strings.Join(gotLeft, ", ") != strings.Join(test.left, ", ") || strings.Join(gotRight, ", ") != strings.Join(test.right, ", ") {
+			t.Errorf("%s: got keys %q = %q, want %q = %q", test.on, gotLeft, gotRight, test.left, test.right)
+		}
+	}
+}
+
+func TestJoins(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE authors (id int, name text);"},
+		{sql: "CREATE TABLE books (id int, author_id int, title text);"},
+		{sql: "INSERT INTO authors VALUES (1, 'ann');"},
+		{sql: "INSERT INTO authors VALUES (2, 'bob');"},
+		{sql: "INSERT INTO authors VALUES (3, 'cid');"},
+		{sql: "INSERT INTO books VALUES (10, 1, 'sea');"},
+		{sql: "INSERT INTO books VALUES (11, 1, 'sky');"},
+		{sql: "INSERT INTO books VALUES (12, 2, 'sun');"},
+		{sql: "INSERT INTO books VALUES (13, 4, 'ice');"},
+
+		{sql: "SELECT name, title FROM authors JOIN books ON authors.id = author_id;", rows: []string{"ann, sea", "ann, sky", "bob, sun"}},
+		{sql: "SELECT a.name, b.title FROM authors a INNER JOIN books AS b ON b.author_id = a.id AND b.title <> 'sky';", rows: []string{"ann, sea", "bob, sun"}},
+		{sql: "SELECT name, title FROM authors a LEFT JOIN books b ON a.id = b.author_id ORDER BY name;", rows: []string{"ann, sea", "ann, sky", "bob, sun", "cid, NULL"}},
+		{sql: "SELECT name, title FROM authors a RIGHT OUTER JOIN books b ON a.id = b.author_id;", rows: []string{"ann, sea", "ann, sky", "bob, sun", "NULL, ice"}},
+		{sql: "SELECT name, title FROM authors a FULL JOIN books b ON a.id = b.author_id;", rows: []string{"ann, sea", "ann, sky", "bob, sun", "cid, NULL", "NULL, ice"}},
+		{sql: "SELECT count(*) FROM authors CROSS JOIN books;", rows: []string{"12"}},
+		{sql: "SELECT count(*) FROM authors, books WHERE authors.id < books.author_id;", rows: []string{"4"}},
+
+		// non-equi conditions are evaluated against every pair of rows
+		{sql: "SELECT a.name, b.title FROM authors a JOIN books b ON a.id + 10 < b.id ORDER BY b.id, a.id;", rows: []string{"ann, sun", "ann, ice", "bob, ice"}},
+		{sql: "SELECT a.name, count(b.id) FROM authors a LEFT JOIN books b ON a.id = b.author_id GROUP BY a.name ORDER BY 2 DESC, 1;", rows: []string{"ann, 2", "bob, 1", "cid, 0"}},
+
+		// a self join must alias the table
+		{sql: "SELECT x.name, y.name FROM authors x JOIN authors y ON x.id + 1 = y.id;", rows: []string{"ann, bob", "bob, cid"}},
+		{sql: "SELECT * FROM authors JOIN authors ON id = id;", err: ErrDuplicateTableName},
+
+		// USING merges the joined columns into one, placed first
+		{sql: "CREATE TABLE ages (id int, age int);"},
+		{sql: "INSERT INTO ages VALUES (1, 31);"},
+		{sql: "INSERT INTO ages VALUES (4, 44);"},
+		{sql: "SELECT * FROM authors JOIN ages USING (id);", rows: []string{"1, ann, 31"}},
+		{sql: "SELECT id, name, age FROM authors FULL JOIN ages USING (id) ORDER BY id;", rows: []string{"1, ann, 31", "2, bob, NULL", "3, cid, NULL", "4, NULL, 44"}},
+		{sql: "SELECT ages.id, authors.id FROM authors RIGHT JOIN ages USING (id);", rows: []string{"1, 1", "4, NULL"}},
+
+		{sql: "SELECT id FROM authors JOIN books ON authors.id = books.author_id;", err: ErrAmbiguousColumn},
+		{sql: "SELECT name FROM authors JOIN books ON authors.id = books.missing;", err: ErrColumnDoesNotExist},
+		{sql: "SELECT * FROM authors JOIN books USING (name);", err: ErrColumnDoesNotExist},
+		{sql: "SELECT * FROM authors JOIN books ON authors.name = books.id;", err: ErrInvalidOperands},
+		{sql: "SELECT * FROM authors JOIN missing ON 1 = 1;", err: ErrTableDoesNotExist},
+	})
+}
+
+func TestJoinUsingTypes(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE i (k int, a text);"},
+		{sql: "CREATE TABLE n (k numeric, b text);"},
+		{sql: "CREATE TABLE f (k real, c text);"},
+		{sql: "INSERT INTO i VALUES (1, 'i1'), (2, 'i2'), (NULL, 'i0');"},
+		{sql: "INSERT INTO n VALUES (1.00, 'n1'), (2.5, 'n2'), (NULL, 'n0');"},
+		{sql: "INSERT INTO f VALUES (1, 'f1'), (2, 'f2');"},
+
+		// the joined columns are compared as, and take, the type both sides
+		// are promoted to
+		{sql: "SELECT k, a, b FROM i JOIN n USING (k);", rows: []string{"1, i1, n1"}},
+		{sql: "SELECT k, a, b FROM i FULL JOIN n USING (k) ORDER BY k;", rows: []string{"1, i1, n1", "2, i2, NULL", "2.5, NULL, n2", "NULL, i0, NULL", "NULL, NULL, n0"}},
+		{sql: "SELECT k, c, a FROM f JOIN i USING (k) ORDER BY k;", rows: []string{"1, f1, i1", "2, f2, i2"}},
+		{sql: "SELECT k + 0.5 FROM n RIGHT JOIN f USING (k) ORDER BY k;", rows: []string{"1.5", "2.5"}},
+		{sql: "SELECT * FROM i JOIN (SELECT 'x' AS k) AS t USING (k);", err: ErrInvalidOperands},
+	})
+}
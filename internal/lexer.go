@@ -16,28 +16,109 @@ type keyword string
 
 // Supported keywords
 const (
-	SELECT keyword = "select"
-	WHERE  keyword = "where"
-	FROM   keyword = "from"
-	AS     keyword = "as"
-	TABLE  keyword = "table"
-	CREATE keyword = "create"
-	INSERT keyword = "insert"
-	INTO   keyword = "into"
-	VALUES keyword = "values"
-	INT    keyword = "int"
-	TEXT   keyword = "text"
+	SELECT     keyword = "select"
+	WHERE      keyword = "where"
+	FROM       keyword = "from"
+	AS         keyword = "as"
+	TABLE      keyword = "table"
+	CREATE     keyword = "create"
+	INSERT     keyword = "insert"
+	INTO       keyword = "into"
+	VALUES     keyword = "values"
+	INT        keyword = "int"
+	TEXT       keyword = "text"
+	REAL       keyword = "real"
+	DOUBLE     keyword = "double"
+	PRECISION  keyword = "precision"
+	NUMERICKW  keyword = "numeric" // NUMERIC is the kind of number tokens
+	DECIMAL    keyword = "decimal"
+	BOOLEAN    keyword = "boolean"
+	BLOB       keyword = "blob"
+	BYTEA      keyword = "bytea"
+	TRUE       keyword = "true"
+	FALSE      keyword = "false"
+	DATE       keyword = "date"
+	TIME       keyword = "time"
+	TIMESTAMP  keyword = "timestamp"
+	INTERVAL   keyword = "interval"
+	WITH       keyword = "with"
+	WITHOUT    keyword = "without"
+	ZONE       keyword = "zone"
+	AND        keyword = "and"
+	OR         keyword = "or"
+	NOT        keyword = "not"
+	ORDER      keyword = "order"
+	BY         keyword = "by"
+	ASC        keyword = "asc"
+	DESC       keyword = "desc"
+	NULLS      keyword = "nulls"
+	FIRST      keyword = "first"
+	LAST       keyword = "last"
+	LIMIT      keyword = "limit"
+	OFFSET     keyword = "offset"
+	GROUP      keyword = "group"
+	HAVING     keyword = "having"
+	DISTINCT   keyword = "distinct"
+	ALL        keyword = "all"
+	UNION      keyword = "union"
+	INTERSECT  keyword = "intersect"
+	EXCEPT     keyword = "except"
+	JOIN       keyword = "join"
+	INNER      keyword = "inner"
+	LEFT       keyword = "left"
+	RIGHT      keyword = "right"
+	FULL       keyword = "full"
+	OUTER      keyword = "outer"
+	CROSS      keyword = "cross"
+	ON         keyword = "on"
+	USING      keyword = "using"
+	EXISTS     keyword = "exists"
+	IN         keyword = "in"
+	UPDATE     keyword = "update"
+	SET        keyword = "set"
+	DELETE     keyword = "delete"
+	TRUNCATE   keyword = "truncate"
+	DROP       keyword = "drop"
+	IF         keyword = "if"
+	ALTER      keyword = "alter"
+	ADD        keyword = "add"
+	COLUMN     keyword = "column"
+	RENAME     keyword = "rename"
+	TO         keyword = "to"
+	DEFAULT    keyword = "default"
+	NULL       keyword = "null"
+	IS         keyword = "is"
+	PRIMARY    keyword = "primary"
+	KEY        keyword = "key"
+	UNIQUE     keyword = "unique"
+	CHECK      keyword = "check"
+	FOREIGN    keyword = "foreign"
+	REFERENCES keyword = "references"
+	CASCADE    keyword = "cascade"
+	RESTRICT   keyword = "restrict"
 )
 
 // symbol represents special
 type symbol string
 
 const (
-	SEMICOLON  symbol = ";"
-	ASTERISK   symbol = "*"
-	COMMA      symbol = ","
-	LEFTPAREN  symbol = "("
-	RIGHTPAREN symbol = ")"
+	SEMICOLON          symbol = ";"
+	ASTERISK           symbol = "*"
+	COMMA              symbol = ","
+	LEFTPAREN          symbol = "("
+	RIGHTPAREN         symbol = ")"
+	EQUALS             symbol = "="
+	NOTEQUALS          symbol = "<>"
+	LESSTHAN           symbol = "<"
+	LESSTHANOREQUAL    symbol = "<="
+	GREATERTHAN        symbol = ">"
+	GREATERTHANOREQUAL symbol = ">="
+	PLUS               symbol = "+"
+	MINUS              symbol = "-"
+	SLASH              symbol = "/"
+	PERCENT            symbol = "%"
+	CONCAT             symbol = "||"
+	DOT                symbol = "."
 )
 
 type tokenKind uint
@@ -48,6 +129,9 @@ const (
 	IDENTIFIER
 	STRING
 	NUMERIC
+	// HEXSTRING is a binary string literal, x'DEADBEEF', its value being the
+	// hex digits
+	HEXSTRING
 )
 
 type token struct {
@@ -73,7 +157,8 @@ func lex(source string) ([]*token, error) {
 
 lex:
 	for cur.pointer < uint(len(source)) {
-		lexers := []lexer{lexKeyword, lexSymbol, lexString, lexNumeric, lexIdentifier}
+		// numerics are lexed before symbols so that ".5" is not read as a DOT
+		lexers := []lexer{lexKeyword, lexNumeric, lexSymbol, lexString, lexHexString, lexIdentifier}
 		for _, lexer := range lexers {
 			if token, newCursor, ok := lexer(source, cur); ok {
 				cur = newCursor
@@ -93,7 +178,9 @@ lex:
 }
 
 func lexIdentifier(source string, ic cursor) (*token, cursor, bool) {
+	// quoted identifiers keep their case
 	if token, newCursor, ok := lexCharacterDelimited(source, ic, '"'); ok {
+		token.kind = IDENTIFIER
 		return token, newCursor, true
 	}
 
@@ -113,9 +200,7 @@ func lexIdentifier(source string, ic cursor) (*token, cursor, bool) {
 	for ; cur.pointer < uint(len(source)); cur.pointer++ {
 		c = source[cur.pointer]
 
-		isAlphabet := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
-		isNumber := c >= '0' && c <= '9'
-		if isAlphabet || isNumber || c == '$' || c == '_' {
+		if isIdentifierChar(c) {
 			value = append(value, c)
 			cur.loc.column++
 			continue
@@ -133,6 +218,12 @@ func lexIdentifier(source string, ic cursor) (*token, cursor, bool) {
 	}, cur, true
 }
 
+func isIdentifierChar(c byte) bool {
+	isAlphabet := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+	isNumber := c >= '0' && c <= '9'
+	return isAlphabet || isNumber || c == '$' || c == '_'
+}
+
 func lexCharacterDelimited(source string, ic cursor, delimiter byte) (*token, cursor, bool) {
 	cur := ic
 
@@ -176,6 +267,27 @@ func lexString(source string, ic cursor) (*token, cursor, bool) {
 	return lexCharacterDelimited(source, ic, '\'')
 }
 
+// lexHexString lexes a binary string literal, an x followed by a string of
+// hex digits, which are checked once evaluated
+func lexHexString(source string, ic cursor) (*token, cursor, bool) {
+	if c := source[ic.pointer]; c != 'x' && c != 'X' {
+		return nil, ic, false
+	}
+	cur := ic
+	cur.pointer++
+	cur.loc.column++
+
+	t, cur, ok := lexCharacterDelimited(source, cur, '\'')
+	if !ok {
+		return nil, ic, false
+	}
+	return &token{
+		value: t.value,
+		loc:   ic.loc,
+		kind:  HEXSTRING,
+	}, cur, true
+}
+
 func lexSymbol(source string, ic cursor) (*token, cursor, bool) {
 	cur := ic
 	c := source[cur.pointer]
@@ -200,6 +312,18 @@ func lexSymbol(source string, ic cursor) (*token, cursor, bool) {
 		RIGHTPAREN,
 		SEMICOLON,
 		ASTERISK,
+		EQUALS,
+		NOTEQUALS,
+		LESSTHAN,
+		LESSTHANOREQUAL,
+		GREATERTHAN,
+		GREATERTHANOREQUAL,
+		PLUS,
+		MINUS,
+		SLASH,
+		PERCENT,
+		CONCAT,
+		DOT,
 	}
 
 	var options []string
@@ -226,6 +350,7 @@ func lexNumeric(source string, ic cursor) (*token, cursor, bool) {
 
 	decimalFound := false
 	exponentFound := false
+	digitFound := false
 
 	for ; cur.pointer < uint(len(source)); cur.pointer++ {
 		c := source[cur.pointer]
@@ -241,6 +366,7 @@ func lexNumeric(source string, ic cursor) (*token, cursor, bool) {
 				return nil, ic, false
 			}
 			decimalFound = isDecimal
+			digitFound = isDigit
 			continue
 		}
 		if isDecimal {
@@ -270,8 +396,10 @@ func lexNumeric(source string, ic cursor) (*token, cursor, bool) {
 		if !isDigit {
 			break
 		}
+		digitFound = true
 	}
-	if cur.pointer == ic.pointer {
+	// a lone decimal point is not a number
+	if cur.pointer == ic.pointer || !digitFound {
 		return nil, ic, false
 	}
 
@@ -295,7 +423,76 @@ func lexKeyword(source string, ic cursor) (*token, cursor, bool) {
 		INTO,
 		INT,
 		TEXT,
+		REAL,
+		DOUBLE,
+		PRECISION,
+		NUMERICKW,
+		DECIMAL,
+		BOOLEAN,
+		BLOB,
+		BYTEA,
+		TRUE,
+		FALSE,
+		DATE,
+		TIME,
+		TIMESTAMP,
+		INTERVAL,
+		WITH,
+		WITHOUT,
+		ZONE,
 		AS,
+		AND,
+		OR,
+		NOT,
+		ORDER,
+		BY,
+		ASC,
+		DESC,
+		NULLS,
+		FIRST,
+		LAST,
+		LIMIT,
+		OFFSET,
+		GROUP,
+		HAVING,
+		DISTINCT,
+		ALL,
+		UNION,
+		INTERSECT,
+		EXCEPT,
+		JOIN,
+		INNER,
+		LEFT,
+		RIGHT,
+		FULL,
+		OUTER,
+		CROSS,
+		ON,
+		USING,
+		EXISTS,
+		IN,
+		UPDATE,
+		SET,
+		DELETE,
+		TRUNCATE,
+		DROP,
+		IF,
+		ALTER,
+		ADD,
+		COLUMN,
+		RENAME,
+		TO,
+		DEFAULT,
+		NULL,
+		IS,
+		PRIMARY,
+		KEY,
+		UNIQUE,
+		CHECK,
+		FOREIGN,
+		REFERENCES,
+		CASCADE,
+		RESTRICT,
 	}
 
 	var options []string
@@ -311,6 +508,12 @@ func lexKeyword(source string, ic cursor) (*token, cursor, bool) {
 	cur.pointer = ic.pointer + uint(len(match))
 	cur.loc.column = ic.loc.column + uint(len(match))
 
+	// a keyword followed by identifier characters is the prefix of an
+	// identifier (e.g. "order" in "orders"), not a keyword
+	if cur.pointer < uint(len(source)) && isIdentifierChar(source[cur.pointer]) {
+		return nil, ic, false
+	}
+
 	return &token{
 		value: match,
 		loc:   ic.loc,
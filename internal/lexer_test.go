@@ -0,0 +1,52 @@
+package godb
+
+import "testing"
+
+// checkLex lexes source, checking it yields the tokens want
+func checkLex(t *testing.T, source string, want []token) {
+	t.Helper()
+	tokens, err := lex(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(tokens), len(want))
+	}
+	for i, tok := range tokens {
+		if !tok.equals(&want[i]) {
+			t.Errorf("token %d: got %q of kind %d, want %q of kind %d", i, tok.value, tok.kind, want[i].value, want[i].kind)
+		}
+	}
+}
+
+func TestLexKeywordPrefix(t *testing.T) {
+	// keywords are only keywords when no identifier characters follow them
+	checkLex(t, "select orders, notes from andover where x<=1", []token{
+		{value: "select", kind: KEYWORD},
+		{value: "orders", kind: IDENTIFIER},
+		{value: ",", kind: SYMBOL},
+		{value: "notes", kind: IDENTIFIER},
+		{value: "from", kind: KEYWORD},
+		{value: "andover", kind: IDENTIFIER},
+		{value: "where", kind: KEYWORD},
+		{value: "x", kind: IDENTIFIER},
+		{value: "<=", kind: SYMBOL},
+		{value: "1", kind: NUMERIC},
+	})
+}
+
+func TestLexHexString(t *testing.T) {
+	// an x is only a hex string when a quote follows it
+	checkLex(t, "select x'0a', X'FF', x, xs, 'x'", []token{
+		{value: "select", kind: KEYWORD},
+		{value: "0a", kind: HEXSTRING},
+		{value: ",", kind: SYMBOL},
+		{value: "FF", kind: HEXSTRING},
+		{value: ",", kind: SYMBOL},
+		{value: "x", kind: IDENTIFIER},
+		{value: ",", kind: SYMBOL},
+		{value: "xs", kind: IDENTIFIER},
+		{value: ",", kind: SYMBOL},
+		{value: "x", kind: STRING},
+	})
+}
@@ -3,29 +3,109 @@ package godb
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
 	"strconv"
+	"strings"
 )
 
+// MemoryCell is the encoding of a value, nil for NULL
 type MemoryCell []byte
 
-func (mc MemoryCell) AsInt() int64 {
-	var i int64
-	err := binary.Read(bytes.NewBuffer(mc), binary.BigEndian, &i)
-	if err != nil {
-		panic(err)
+// AsInt decodes the cell as an integer, failing if it is NULL or does not
+// hold one
+func (mc MemoryCell) AsInt() (int64, error) {
+	if mc == nil {
+		return 0, ErrNullCell
+	}
+	if len(mc) != 8 {
+		return 0, fmt.Errorf("%w: %d bytes are not an int", ErrInvalidCell, len(mc))
+	}
+	return mc.int(), nil
+}
+
+// AsText decodes the cell as text, failing if it is NULL
+func (mc MemoryCell) AsText() (string, error) {
+	if mc == nil {
+		return "", ErrNullCell
+	}
+	return string(mc), nil
+}
+
+// AsFloat decodes the cell as a float, failing if it is NULL or does not
+// hold one
+func (mc MemoryCell) AsFloat() (float64, error) {
+	if mc == nil {
+		return 0, ErrNullCell
 	}
-	return i
+	if len(mc) != 8 {
+		return 0, fmt.Errorf("%w: %d bytes are not a double precision", ErrInvalidCell, len(mc))
+	}
+	return mc.float(), nil
+}
+
+// int decodes a cell known to hold an integer
+func (mc MemoryCell) int() int64 {
+	return int64(binary.BigEndian.Uint64(mc))
+}
+
+// AsBool decodes the cell as a boolean, failing if it is NULL or does not
+// hold one
+func (mc MemoryCell) AsBool() (bool, error) {
+	if mc == nil {
+		return false, ErrNullCell
+	}
+	if len(mc) != 1 {
+		return false, fmt.Errorf("%w: %d bytes are not a boolean", ErrInvalidCell, len(mc))
+	}
+	return mc.bool(), nil
+}
+
+// bool decodes a cell known to hold a boolean
+func (mc MemoryCell) bool() bool {
+	return mc[0] == 1
+}
+
+// float decodes a cell known to hold a float
+func (mc MemoryCell) float() float64 {
+	return math.Float64frombits(binary.BigEndian.Uint64(mc))
 }
 
-func (mc MemoryCell) AsText() string {
-	return string(mc)
+func (mc MemoryCell) IsNull() bool {
+	return mc == nil
 }
 
 type table struct {
 	columns     []string
 	columnTypes []ColumnType
+	// defaults holds the DEFAULT expression of each column, nil if it has none
+	defaults    []*expression
+	notNull     []bool
+	keys        []*tableKey
+	checks      []*expression
+	foreignKeys []*tableForeignKey
 	rows        [][]MemoryCell
+	// modifiers holds the precision and scale of each NUMERIC(p, s) column,
+	// nil for the other columns
+	modifiers []*numericModifier
+}
+
+// columnIndex returns the position of the named column, -1 if the table (which
+// may be nil) has no such column
+func (t *table) columnIndex(name string) int {
+	if t == nil {
+		return -1
+	}
+	for i, col := range t.columns {
+		if col == name {
+			return i
+		}
+	}
+	return -1
 }
 
 type MemoryBackend struct {
@@ -41,123 +121,2539 @@ func NewMemoryBackend() *MemoryBackend {
 // Implementing the Backend Interface
 
 func (mb *MemoryBackend) CreateTable(crt *CreateStatement) error {
+	if _, ok := mb.tables[crt.name.value]; ok {
+		if crt.ifNotExists {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", ErrTableAlreadyExists, crt.name.value)
+	}
+
 	t := table{}
+	if crt.cols != nil {
+		for _, col := range *crt.cols {
+			if err := mb.addColumn(&t, col); err != nil {
+				return err
+			}
+		}
+	}
+	for _, constraint := range crt.constraints {
+		if constraint.kind == foreignKeyConstraintKind {
+			continue
+		}
+		if err := mb.addConstraint(&t, constraint); err != nil {
+			return err
+		}
+	}
+
+	if err := mb.checkConstraints(crt.name.value, &t); err != nil {
+		return err
+	}
+
+	// foreign keys are added last, as they may reference the keys of the
+	// table itself
+	if crt.cols != nil {
+		for _, col := range *crt.cols {
+			if col.references == nil {
+				continue
+			}
+			if err := mb.addForeignKey(crt.name.value, &t, []token{col.name}, col.references); err != nil {
+				return err
+			}
+		}
+	}
+	for _, constraint := range crt.constraints {
+		if constraint.kind != foreignKeyConstraintKind {
+			continue
+		}
+		if err := mb.addForeignKey(crt.name.value, &t, constraint.columns, constraint.references); err != nil {
+			return err
+		}
+	}
+
+	// the table is only added once its definition is known to be valid
 	mb.tables[crt.name.value] = &t
+	return nil
+}
 
-	if crt.cols == nil {
-		return nil
+// columnType returns the type named by a column definition, whatever its
+// precision and scale
+func columnType(datatype token) (ColumnType, error) {
+	name := datatype.value
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = name[:i]
+	}
+	switch name {
+	case "int":
+		return IntType, nil
+	case "text":
+		return TextType, nil
+	case "real", "double precision":
+		return FloatType, nil
+	case "boolean":
+		return BoolType, nil
+	case "date":
+		return DateType, nil
+	case "time":
+		return TimeType, nil
+	case "timestamp":
+		return TimestampType, nil
+	case "timestamp with time zone":
+		return TimestampTzType, nil
+	case "interval":
+		return IntervalType, nil
+	case "numeric":
+		return NumericType, nil
+	case "bytea":
+		return BlobType, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrInvalidDatatype, datatype.value)
+}
+
+// addColumn appends a column to the definition of the table, leaving its
+// rows untouched
+func (mb *MemoryBackend) addColumn(t *table, col *columnDefinition) error {
+	if t.columnIndex(col.name.value) >= 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateColumnName, col.name.value)
 	}
 
-	for _, col := range *crt.cols {
-		t.columns = append(t.columns, col.name.value)
-		var dt ColumnType
-		switch col.datatype.value {
-		case "int":
-			dt = IntType
-		case "text":
-			dt = TextType
-		default:
-			return ErrInvalidDatatype
+	typ, err := columnType(col.datatype)
+	if err != nil {
+		return err
+	}
+	mod, err := columnModifier(col.datatype)
+	if err != nil {
+		return err
+	}
+
+	// defaults cannot refer to columns, they are evaluated in an empty scope,
+	// nor hold subqueries. String literals are read as values of the column
+	// type.
+	if col.def != nil && hasSubquery(col.def) {
+		return fmt.Errorf("%w: %s default for %s", ErrInvalidSubquery, col.def.generateCode(), col.name.value)
+	}
+	if isStringLiteral(col.def) {
+		if _, err := parseCell(col.def.literal.value, typ); err != nil {
+			return fmt.Errorf("%w: '%s' default for %s of type %s", ErrValueTypeMismatch, col.def.literal.value, col.name.value, typ)
+		}
+	} else if col.def != nil {
+		defType, err := mb.expressionType(&scope{}, col.def)
+		if err != nil {
+			return err
+		}
+		if !assignable(defType, typ) && !(typ == IntType && mayBeInteger(defType)) {
+			return fmt.Errorf("%w: %s default for %s of type %s", ErrValueTypeMismatch, defType, col.name.value, typ)
 		}
+	}
+
+	t.columns = append(t.columns, col.name.value)
+	t.columnTypes = append(t.columnTypes, typ)
+	t.defaults = append(t.defaults, col.def)
+	t.modifiers = append(t.modifiers, mod)
+	return mb.addColumnConstraints(t, col)
+}
+
+// columnDefault evaluates the default of a column of the table name, NULL if
+// it has none
+func (mb *MemoryBackend) columnDefault(name string, t *table, i int) (MemoryCell, error) {
+	if t.defaults[i] == nil {
+		return nil, nil
+	}
+	def := t.defaults[i]
+	c, typ, err := mb.evaluateCell(&scope{}, def)
+	if err != nil {
+		return nil, err
+	}
+	return columnValue(name, t, i, def, c, typ)
+}
+
+// AlterTable changes the definition of a table, rewriting its rows to match
+func (mb *MemoryBackend) AlterTable(alt *AlterStatement) error {
+	t, ok := mb.tables[alt.table.value]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTableDoesNotExist, alt.table.value)
+	}
+
+	switch alt.kind {
+	case addColumnAlterKind:
+		// the table is changed only once the column is known to be valid
+		altered := *t
+		altered.columns = append([]string{}, t.columns...)
+		altered.columnTypes = append([]ColumnType{}, t.columnTypes...)
+		altered.defaults = append([]*expression{}, t.defaults...)
+		altered.modifiers = append([]*numericModifier{}, t.modifiers...)
+		altered.notNull = append([]bool{}, t.notNull...)
+		altered.keys = append([]*tableKey{}, t.keys...)
+		altered.checks = append([]*expression{}, t.checks...)
+		altered.foreignKeys = append([]*tableForeignKey{}, t.foreignKeys...)
+		if err := mb.addColumn(&altered, alt.column); err != nil {
+			return err
+		}
+		if err := mb.checkConstraints(alt.table.value, &altered); err != nil {
+			return err
+		}
+		if alt.column.references != nil {
+			if err := mb.addForeignKey(alt.table.value, &altered, []token{alt.column.name}, alt.column.references); err != nil {
+				return err
+			}
+		}
+
+		// existing rows take the default, rows are replaced rather than
+		// extended in place so results already read keep their shape
+		altered.rows = nil
+		for _, row := range t.rows {
+			c, err := mb.columnDefault(alt.table.value, &altered, len(altered.columns)-1)
+			if err != nil {
+				return err
+			}
+			altered.rows = append(altered.rows, append(append([]MemoryCell{}, row...), c))
+		}
+
+		cs := newChangeSet()
+		cs.definitions[alt.table.value] = &altered
+		cs.rows[alt.table.value] = altered.rows
+		cs.changed[alt.table.value] = altered.rows
+		if err := mb.commit(cs); err != nil {
+			return err
+		}
+		*t = altered
+	case dropColumnAlterKind:
+		i := t.columnIndex(alt.name.value)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrColumnDoesNotExist, alt.name.value)
+		}
+
+		if err := mb.dropColumnForeignKeys(alt.table.value, t, i); err != nil {
+			return err
+		}
+		dropColumnConstraints(t, i)
+		t.columns = append(append([]string{}, t.columns[:i]...), t.columns[i+1:]...)
+		t.columnTypes = append(append([]ColumnType{}, t.columnTypes[:i]...), t.columnTypes[i+1:]...)
+		t.defaults = append(append([]*expression{}, t.defaults[:i]...), t.defaults[i+1:]...)
+		t.modifiers = append(append([]*numericModifier{}, t.modifiers[:i]...), t.modifiers[i+1:]...)
+		rows := make([][]MemoryCell, 0, len(t.rows))
+		for _, row := range t.rows {
+			rows = append(rows, append(append([]MemoryCell{}, row[:i]...), row[i+1:]...))
+		}
+		t.rows = rows
+	case renameColumnAlterKind:
+		i := t.columnIndex(alt.name.value)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrColumnDoesNotExist, alt.name.value)
+		}
+		if t.columnIndex(alt.newName.value) >= 0 {
+			return fmt.Errorf("%w: %s", ErrDuplicateColumnName, alt.newName.value)
+		}
+
+		renameConstraintRefs(t, false, alt.name.value, alt.newName.value)
+		t.columns[i] = alt.newName.value
+	case renameTableAlterKind:
+		if _, ok := mb.tables[alt.newName.value]; ok {
+			return fmt.Errorf("%w: %s", ErrTableAlreadyExists, alt.newName.value)
+		}
+
+		renameConstraintRefs(t, true, alt.table.value, alt.newName.value)
+		for _, other := range mb.tables {
+			for _, fk := range other.foreignKeys {
+				if fk.table == alt.table.value {
+					fk.table = alt.newName.value
+				}
+			}
+		}
+		delete(mb.tables, alt.table.value)
+		mb.tables[alt.newName.value] = t
+	}
+	return nil
+}
+
+// DropTable removes the tables, none of them if any does not exist unless IF
+// EXISTS is given
+func (mb *MemoryBackend) DropTable(drp *DropStatement) error {
+	if !drp.ifExists {
+		for _, name := range drp.names {
+			if _, ok := mb.tables[name.value]; !ok {
+				return fmt.Errorf("%w: %s", ErrTableDoesNotExist, name.value)
+			}
+		}
+	}
 
-		t.columnTypes = append(t.columnTypes, dt)
+	// tables cannot be dropped while referenced by tables that are not
+	dropped := map[string]bool{}
+	for _, name := range drp.names {
+		dropped[name.value] = true
+	}
+	for _, name := range drp.names {
+		for _, child := range mb.referencingTables(name.value) {
+			if !dropped[child] {
+				return fmt.Errorf("%w: %s is referenced by %s", ErrTableReferenced, name.value, child)
+			}
+		}
+	}
+
+	for _, name := range drp.names {
+		delete(mb.tables, name.value)
 	}
 	return nil
 }
 
+// Insert adds the rows of values, all of them or none if any fails. Columns
+// left out of the column list take their default, or NULL without one.
 func (mb *MemoryBackend) Insert(inst *InsertStatement) error {
-	table, ok := mb.tables[inst.table.value]
+	t, ok := mb.tables[inst.table.value]
 	if !ok {
-		return ErrTableDoesNotExist
+		return fmt.Errorf("%w: %s", ErrTableDoesNotExist, inst.table.value)
 	}
-	if inst.values == nil {
-		return nil
+
+	targets, err := insertTargets(t, inst.columns)
+	if err != nil {
+		return err
 	}
 
-	if len(*inst.values) != len(table.columns) {
-		return ErrMissingValues
+	if inst.query != nil {
+		return mb.insertQuery(t, inst, targets)
 	}
 
-	var row []MemoryCell
+	var rows [][]MemoryCell
+	for _, values := range inst.values {
+		if err := checkValueCount(targets, len(values)); err != nil {
+			return err
+		}
 
-	for _, value := range *inst.values {
-		if value.kind != literalKind {
-			fmt.Println("Skipping non-literal")
-			continue
+		// values are constant expressions, they cannot refer to any column
+		var cells []MemoryCell
+		for i, value := range values {
+			c, typ, err := mb.evaluateCell(&scope{}, value)
+			if err != nil {
+				return err
+			}
+			c, err = columnValue(inst.table.value, t, targets[i], value, c, typ)
+			if err != nil {
+				return err
+			}
+			cells = append(cells, c)
 		}
-		row = append(row, mb.tokenToCell(value.literal))
+
+		row, err := mb.newRow(inst.table.value, t, targets, cells)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
 	}
-	table.rows = append(table.rows, row)
-	return nil
+
+	return mb.appendRows(inst.table.value, t, rows)
 }
 
-func (mb *MemoryBackend) tokenToCell(t *token) MemoryCell {
-	if t.kind == NUMERIC {
-		buf := new(bytes.Buffer)
-		i, err := strconv.Atoi(t.value)
-		if err != nil {
-			panic(err)
+// insertQuery adds the rows of the query of an INSERT ... SELECT, whose
+// columns must have the types of the target columns
+func (mb *MemoryBackend) insertQuery(t *table, inst *InsertStatement, targets []int) error {
+	results, err := mb.Select(inst.query)
+	if err != nil {
+		return err
+	}
+
+	if err := checkValueCount(targets, len(results.Columns)); err != nil {
+		return err
+	}
+	for i, col := range results.Columns {
+		// whether numerics are integers is only known row by row
+		to := t.columnTypes[targets[i]]
+		if !assignable(col.Type, to) && !(to == IntType && mayBeInteger(col.Type)) {
+			return fmt.Errorf("%w: %s column %s for %s.%s of type %s", ErrValueTypeMismatch, col.Type, col.Name, inst.table.value, t.columns[targets[i]], t.columnTypes[targets[i]])
+		}
+	}
+
+	var rows [][]MemoryCell
+	for _, result := range results.Row {
+		cells := make([]MemoryCell, len(result))
+		for i, c := range result {
+			if cells[i], err = columnValue(inst.table.value, t, targets[i], nil, c.(MemoryCell), results.Columns[i].Type); err != nil {
+				return err
+			}
 		}
 
-		err = binary.Write(buf, binary.BigEndian, int64(i))
+		row, err := mb.newRow(inst.table.value, t, targets, cells)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		return MemoryCell(buf.Bytes())
-	} else if t.kind == STRING {
-		return MemoryCell(t.value)
+		rows = append(rows, row)
+	}
+
+	return mb.appendRows(inst.table.value, t, rows)
+}
+
+// checkValueCount checks that as many values are given as there are target
+// columns
+func checkValueCount(targets []int, n int) error {
+	if n < len(targets) {
+		return fmt.Errorf("%w: expected %d values, got %d", ErrMissingValues, len(targets), n)
+	}
+	if n > len(targets) {
+		return fmt.Errorf("%w: expected %d values, got %d", ErrTooManyValues, len(targets), n)
 	}
 	return nil
 }
 
-func (mb *MemoryBackend) Select(slct *SelectStatement) (*Results, error) {
-	table, ok := mb.tables[slct.from.value]
-	if !ok {
-		return nil, ErrTableDoesNotExist
+// insertTargets resolves the column list of an INSERT to column positions,
+// every column of the table in order if the list is empty
+func insertTargets(t *table, columns []token) ([]int, error) {
+	var targets []int
+	if len(columns) == 0 {
+		for i := range t.columns {
+			targets = append(targets, i)
+		}
+		return targets, nil
 	}
 
-	var results [][]Cell
-	var columns []struct {
-		Type ColumnType
-		Name string
+	for _, column := range columns {
+		i := t.columnIndex(column.value)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrColumnDoesNotExist, column.value)
+		}
+		for _, target := range targets {
+			if target == i {
+				return nil, fmt.Errorf("%w: %s", ErrDuplicateColumnName, column.value)
+			}
+		}
+		targets = append(targets, i)
 	}
+	return targets, nil
+}
 
-	for i, row := range table.rows {
-		var result []Cell
-		isFirstRow := i == 0
+// appendRows adds new rows to the table once they are known to satisfy its
+// constraints
+func (mb *MemoryBackend) appendRows(name string, t *table, rows [][]MemoryCell) error {
+	cs := newChangeSet()
+	cs.rows[name] = append(append([][]MemoryCell{}, t.rows...), rows...)
+	cs.changed[name] = rows
+	return mb.commit(cs)
+}
 
-		for _, exp := range slct.item {
-			if exp.kind != literalKind {
-				fmt.Println("skipping non-literal kinds, for now")
-				continue
+// newRow builds a row of the table name from the values of the target
+// columns, the others taking their default
+func (mb *MemoryBackend) newRow(name string, t *table, targets []int, values []MemoryCell) ([]MemoryCell, error) {
+	row := make([]MemoryCell, len(t.columns))
+	set := make([]bool, len(t.columns))
+	for i, target := range targets {
+		row[target] = values[i]
+		set[target] = true
+	}
+
+	for i := range row {
+		if set[i] {
+			continue
+		}
+		c, err := mb.columnDefault(name, t, i)
+		if err != nil {
+			return nil, err
+		}
+		row[i] = c
+	}
+	return row, nil
+}
+
+// Update sets the columns of the rows matching the WHERE clause, returning the
+// number of rows updated. The values are evaluated against the rows as they
+// were before the update, and no row is changed if any of them fails.
+func (mb *MemoryBackend) Update(upd *UpdateStatement) (int, error) {
+	t, columns, err := mb.tableColumns(&fromItem{table: &upd.table, kind: tableFromKind})
+	if err != nil {
+		return 0, err
+	}
+	rel := &relation{columns: columns, rows: t.rows}
+
+	targets := make([]int, len(upd.set))
+	for i, set := range upd.set {
+		targets[i] = t.columnIndex(set.column.value)
+		if targets[i] < 0 {
+			return 0, fmt.Errorf("%w: %s", ErrColumnDoesNotExist, set.column.value)
+		}
+		for _, target := range targets[:i] {
+			if target == targets[i] {
+				return 0, fmt.Errorf("%w: %s", ErrDuplicateColumnName, set.column.value)
 			}
-			lit := exp.literal
-			if lit.kind == IDENTIFIER {
-				found := false
-				for i, tableCol := range table.columns {
-					if tableCol == lit.value {
-						if isFirstRow {
-							columns = append(columns, struct {
-								Type ColumnType
-								Name string
-							}{
-								Type: table.columnTypes[i],
-								Name: lit.value,
-							})
-						}
-						result = append(result, row[i])
-						found = true
-						break
-					}
-				}
-				if !found {
-					return nil, ErrColumnDoesNotExist
-				}
+		}
+	}
+
+	updated := map[int][]MemoryCell{}
+	for i, row := range t.rows {
+		sc := &scope{relation: rel, row: row}
+		if upd.where != nil {
+			ok, err := mb.evaluatePredicate(sc, upd.where)
+			if err != nil {
+				return 0, err
+			}
+			if !ok {
 				continue
 			}
-			return nil, ErrColumnDoesNotExist
 		}
-		results = append(results, result)
+
+		row = append([]MemoryCell{}, row...)
+		for j, set := range upd.set {
+			c, typ, err := mb.evaluateCell(sc, set.value)
+			if err != nil {
+				return 0, err
+			}
+			c, err = columnValue(upd.table.value, t, targets[j], set.value, c, typ)
+			if err != nil {
+				return 0, err
+			}
+			row[targets[j]] = c
+		}
+		updated[i] = row
+	}
+
+	// rows are replaced rather than changed in place, results already read
+	// from the table keep the values they had
+	cs := newChangeSet()
+	var rows [][]MemoryCell
+	var changes []rowChange
+	for i, row := range t.rows {
+		if u, ok := updated[i]; ok {
+			changes = append(changes, rowChange{old: row, new: u})
+			cs.changed[upd.table.value] = append(cs.changed[upd.table.value], u)
+			row = u
+		}
+		rows = append(rows, row)
+	}
+	cs.rows[upd.table.value] = rows
+
+	if err := mb.propagate(cs, upd.table.value, changes); err != nil {
+		return 0, err
+	}
+	if err := mb.commit(cs); err != nil {
+		return 0, err
+	}
+	return len(updated), nil
+}
+
+// Delete removes the rows matching the WHERE clause, returning the number of
+// rows deleted. No row is removed if the predicate fails on any of them.
+func (mb *MemoryBackend) Delete(del *DeleteStatement) (int, error) {
+	t, columns, err := mb.tableColumns(&fromItem{table: &del.table, kind: tableFromKind})
+	if err != nil {
+		return 0, err
+	}
+
+	rel := &relation{columns: columns, rows: t.rows}
+	var kept [][]MemoryCell
+	var changes []rowChange
+	for _, row := range t.rows {
+		ok := true
+		if del.where != nil {
+			ok, err = mb.evaluatePredicate(&scope{relation: rel, row: row}, del.where)
+			if err != nil {
+				return 0, err
+			}
+		}
+		if ok {
+			changes = append(changes, rowChange{old: row})
+		} else {
+			kept = append(kept, row)
+		}
+	}
+
+	// rows referencing the deleted rows are deleted or changed along with
+	// them, as their foreign keys require
+	cs := newChangeSet()
+	cs.rows[del.table.value] = kept
+	if err := mb.propagate(cs, del.table.value, changes); err != nil {
+		return 0, err
+	}
+	if err := mb.commit(cs); err != nil {
+		return 0, err
+	}
+	return len(changes), nil
+}
+
+// tokenToCell encodes a numeric or string literal, returning its type
+func (mb *MemoryBackend) tokenToCell(t *token) (MemoryCell, ColumnType, error) {
+	switch t.kind {
+	case NUMERIC:
+		typ := numericLiteralType(t.value)
+		c, err := parseCell(t.value, typ)
+		return c, typ, err
+	case STRING:
+		return textCell(t.value), TextType, nil
+	case HEXSTRING:
+		b, err := hex.DecodeString(t.value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: x'%s' is not a bytea", ErrInvalidCell, t.value)
+		}
+		return blobCell(b), BlobType, nil
+	}
+	return nil, 0, ErrInvalidOperands
+}
+
+// numericLiteralType returns the type of a numeric literal, those with a
+// decimal point or an exponent, or too large for an int, being exact
+// numerics
+func numericLiteralType(value string) ColumnType {
+	if strings.ContainsAny(value, ".eE") {
+		return NumericType
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return NumericType
+	}
+	return IntType
+}
+
+// textCell encodes a string, never as nil so that the empty string is not
+// taken for NULL
+func textCell(s string) MemoryCell {
+	c := make(MemoryCell, len(s))
+	copy(c, s)
+	return c
+}
+
+func intCell(i int64) MemoryCell {
+	c := make(MemoryCell, 8)
+	binary.BigEndian.PutUint64(c, uint64(i))
+	return c
+}
+
+// floatCell encodes a float as IEEE-754, with negative zero as zero so that
+// equal values are encoded alike
+func floatCell(f float64) MemoryCell {
+	if f == 0 {
+		f = 0
+	}
+	c := make(MemoryCell, 8)
+	binary.BigEndian.PutUint64(c, math.Float64bits(f))
+	return c
+}
+
+func boolCell(b bool) MemoryCell {
+	if b {
+		return MemoryCell{1}
+	}
+	return MemoryCell{0}
+}
+
+// convertCell converts a cell of the type from to the type to, which values
+// of from are promoted or assigned to. Timestamps are encoded alike with or
+// without a time zone.
+func convertCell(c MemoryCell, from, to ColumnType) MemoryCell {
+	for c != nil && from != to {
+		switch from {
+		case IntType:
+			c = numericCell(intDecimal(c.int()))
+		case NumericType:
+			f, _ := c.decimal().Rat().Float64()
+			c = floatCell(f)
+		case DateType:
+			c = intCell(int64(c.date()) * usecPerDay)
+		}
+
+		next, ok := promotions[from]
+		if !ok {
+			break
+		}
+		from = next
+	}
+	return c
+}
+
+// formatFloat renders a float in the shortest form that reads back as it,
+// in exponent notation only for very large or small magnitudes
+func formatFloat(f float64) string {
+	abs := math.Abs(f)
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case abs != 0 && (abs < 1e-4 || abs >= 1e15):
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// cellText renders a cell of the type typ as text
+func cellText(c MemoryCell, typ ColumnType) string {
+	if c == nil {
+		return "NULL"
+	}
+	switch typ {
+	case IntType:
+		return strconv.FormatInt(c.int(), 10)
+	case FloatType:
+		return formatFloat(c.float())
+	case BoolType:
+		return strconv.FormatBool(c.bool())
+	case DateType, TimeType, TimestampType, TimestampTzType:
+		return formatTime(cellTime(c, typ), typ)
+	case IntervalType:
+		return c.interval().String()
+	case NumericType:
+		return c.decimal().String()
+	case BlobType:
+		return formatBlob(c)
+	}
+	return string(c)
+}
+
+// valueText renders a cell of the type typ as a literal for error messages
+func valueText(c MemoryCell, typ ColumnType) string {
+	if c != nil && typ == TextType {
+		return fmt.Sprintf("'%s'", c)
+	}
+	return cellText(c, typ)
+}
+
+// columnValue checks that a value given for the column at i of the table
+// name has the column type. String literals are read as values of the
+// column type, as values of any type can be written as one, and numerics
+// without a fraction as integers.
+func columnValue(name string, t *table, i int, exp *expression, c MemoryCell, typ ColumnType) (MemoryCell, error) {
+	colType := t.columnTypes[i]
+	if c == nil || typ == colType {
+		return fitNumeric(name, t, i, c)
+	}
+	if assignable(typ, colType) {
+		return fitNumeric(name, t, i, convertCell(c, typ, colType))
+	}
+	if colType == IntType && mayBeInteger(typ) {
+		ic, err := integerCell(c, typ)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s for %s.%s of type %s", err, valueText(c, typ), name, t.columns[i], colType)
+		}
+		if ic != nil {
+			return ic, nil
+		}
+	}
+
+	if isStringLiteral(exp) {
+		if parsed, err := parseCell(exp.literal.value, colType); err == nil {
+			return fitNumeric(name, t, i, parsed)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s for %s.%s of type %s", ErrValueTypeMismatch, valueText(c, typ), name, t.columns[i], colType)
+}
+
+// parseCell reads the text of a value of the type typ
+func parseCell(s string, typ ColumnType) (MemoryCell, error) {
+	switch typ {
+	case IntType:
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return nil, fmt.Errorf("%w: %s is out of range for int", ErrInvalidCell, s)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: '%s' is not an int", ErrInvalidCell, s)
+		}
+		return intCell(i), nil
+	case FloatType:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: '%s' is not a double precision", ErrInvalidCell, s)
+		}
+		return floatCell(f), nil
+	case NumericType:
+		d, err := parseDecimal(s)
+		if err != nil {
+			return nil, err
+		}
+		return numericCell(d), nil
+	case BoolType:
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "t", "true", "y", "yes", "on", "1":
+			return boolCell(true), nil
+		case "f", "false", "n", "no", "off", "0":
+			return boolCell(false), nil
+		}
+		return nil, fmt.Errorf("%w: '%s' is not a boolean", ErrInvalidCell, s)
+	case DateType, TimeType, TimestampType, TimestampTzType, IntervalType:
+		return parseTemporal(s, typ)
+	case BlobType:
+		return parseBlob(s)
+	case TextType:
+		return textCell(s), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidDatatype, typ)
+}
+
+// scope is what expressions are evaluated against: a row of the relation
+// being read and, when aggregating, every row of the group it represents.
+// The scope of a subquery is nested in that of the row it is evaluated for.
+type scope struct {
+	relation    *relation
+	row         []MemoryCell
+	group       [][]MemoryCell
+	aggregating bool
+	outer       *scope
+}
+
+// nullType is the type of a NULL literal, which takes the type of whatever
+// it is combined with
+const nullType = ^ColumnType(0)
+
+// unifyTypes returns the type values of the types a and b can be compared or
+// combined as, false if they are incompatible
+func unifyTypes(a, b ColumnType) (ColumnType, bool) {
+	if a == nullType {
+		return b, true
+	}
+	if b == nullType || a == b {
+		return a, true
+	}
+	if promotes(a, b) {
+		return b, true
+	}
+	if promotes(b, a) {
+		return a, true
+	}
+	return a, false
+}
+
+// promotions are the types values are implicitly converted to when combined
+// with values of those types
+var promotions = map[ColumnType]ColumnType{
+	IntType:       NumericType,
+	NumericType:   FloatType,
+	DateType:      TimestampType,
+	TimestampType: TimestampTzType,
+}
+
+// promotes reports whether values of the type from are promoted, possibly
+// in steps, to the type to
+func promotes(from, to ColumnType) bool {
+	for typ, ok := promotions[from]; ok; typ, ok = promotions[typ] {
+		if typ == to {
+			return true
+		}
+	}
+	return false
+}
+
+// assignable reports whether values of the type from can be stored in a
+// column of the type to: NULLs, values promoted to it and, the session time
+// zone being UTC, timestamps with a time zone in timestamps without
+func assignable(from, to ColumnType) bool {
+	return from == to || from == nullType || promotes(from, to) || (from == TimestampTzType && to == TimestampType)
+}
+
+// isStringLiteral reports whether the expression is a string literal without
+// a type name, which is read as a value of whatever type it is used as
+func isStringLiteral(exp *expression) bool {
+	return exp != nil && exp.kind == literalKind && exp.literal.kind == STRING && exp.typeName == nil
+}
+
+// literalOperand reads a string literal compared with a value of the type
+// other as a value of that type, e.g. in created > '2024-01-01'
+func literalOperand(exp *expression, c MemoryCell, typ, other ColumnType) (MemoryCell, ColumnType, error) {
+	if !isStringLiteral(exp) || other == TextType || other == nullType {
+		return c, typ, nil
+	}
+	c, err := parseCell(exp.literal.value, other)
+	return c, other, err
+}
+
+// isIntType reports whether values of the type can be used as integers
+func isIntType(typ ColumnType) bool {
+	return typ == IntType || typ == nullType
+}
+
+// isNumericType reports whether values of the type can be used in
+// arithmetic
+func isNumericType(typ ColumnType) bool {
+	return isIntType(typ) || typ == NumericType || typ == FloatType
+}
+
+// arithmeticType returns the type of the result of arithmetic on values of
+// the numeric types a and b, that which the other is promoted to
+func arithmeticType(a, b ColumnType) ColumnType {
+	typ, _ := unifyTypes(a, b)
+	if typ == nullType {
+		return IntType
+	}
+	return typ
+}
+
+// lookup resolves a column reference in the scope or, failing that, in the
+// enclosing scopes, returning the scope holding the column
+func (sc *scope) lookup(qualifier *token, name string) (*scope, int, error) {
+	i, err := sc.relation.columnIndex(qualifier, name)
+	if errors.Is(err, ErrColumnDoesNotExist) && sc.outer != nil {
+		return sc.outer.lookup(qualifier, name)
+	}
+	if err != nil {
+		return nil, -1, err
+	}
+	return sc, i, nil
+}
+
+// expressionType infers the type of a value expression in the scope, without
+// reading its row
+func (mb *MemoryBackend) expressionType(sc *scope, exp *expression) (ColumnType, error) {
+	if isPredicate(exp) {
+		return mb.predicateType(sc, exp)
+	}
+
+	switch exp.kind {
+	case literalKind:
+		lit := exp.literal
+		switch lit.kind {
+		case IDENTIFIER:
+			owner, i, err := sc.lookup(exp.qualifier, lit.value)
+			if err != nil {
+				return 0, err
+			}
+			return owner.relation.columns[i].typ, nil
+		case NUMERIC:
+			return numericLiteralType(lit.value), nil
+		case HEXSTRING:
+			return BlobType, nil
+		case STRING:
+			if exp.typeName != nil {
+				return columnType(*exp.typeName)
+			}
+			return TextType, nil
+		case KEYWORD:
+			switch keyword(lit.value) {
+			case NULL:
+				return nullType, nil
+			case TRUE, FALSE:
+				return BoolType, nil
+			}
+		}
+	case unaryKind:
+		typ, err := mb.expressionType(sc, exp.unary.operand)
+		if err != nil {
+			return 0, err
+		}
+		if exp.unary.op.kind == SYMBOL && typ == IntervalType {
+			return typ, nil
+		}
+		if exp.unary.op.kind != SYMBOL || !isNumericType(typ) {
+			return 0, ErrInvalidOperands
+		}
+		return arithmeticType(typ, typ), nil
+	case binaryKind:
+		bin := exp.binary
+		aType, err := mb.expressionType(sc, bin.a)
+		if err != nil {
+			return 0, err
+		}
+		bType, err := mb.expressionType(sc, bin.b)
+		if err != nil {
+			return 0, err
+		}
+		switch symbol(bin.op.value) {
+		case CONCAT:
+			return concatType(aType, bType), nil
+		case PLUS, MINUS, ASTERISK, SLASH, PERCENT:
+			if isTemporalType(aType) || isTemporalType(bType) {
+				o, err := resolveTemporalOperator(bin.op, aType, bType)
+				if err != nil {
+					return 0, err
+				}
+				return o.result, nil
+			}
+			if !isNumericType(aType) || !isNumericType(bType) {
+				return 0, ErrInvalidOperands
+			}
+			return arithmeticType(aType, bType), nil
+		}
+	case functionKind:
+		return mb.functionType(sc, exp.function)
+	case subqueryKind:
+		columns, err := mb.describe(exp.subquery, sc)
+		if err != nil {
+			return 0, err
+		}
+		if len(columns) != 1 {
+			return 0, ErrSubqueryColumns
+		}
+		return columns[0].Type, nil
+	}
+	return 0, ErrInvalidOperands
+}
+
+// isPredicate reports whether the expression is a comparison or a logical
+// operation, evaluated in three-valued logic
+func isPredicate(exp *expression) bool {
+	switch exp.kind {
+	case existsKind, inKind, isNullKind:
+		return true
+	case unaryKind:
+		return exp.unary.op.kind == KEYWORD
+	case binaryKind:
+		power := binaryOperatorPower(&exp.binary.op)
+		return power > 0 && power <= comparisonPower
+	}
+	return false
+}
+
+// predicateType checks the operands of a predicate, returning the type of
+// its value
+func (mb *MemoryBackend) predicateType(sc *scope, exp *expression) (ColumnType, error) {
+	switch exp.kind {
+	case existsKind:
+		if _, err := mb.describe(exp.subquery, sc); err != nil {
+			return 0, err
+		}
+	case inKind:
+		typ, err := mb.expressionType(sc, exp.in.operand)
+		if err != nil {
+			return 0, err
+		}
+		if exp.in.subquery != nil {
+			columns, err := mb.describe(exp.in.subquery, sc)
+			if err != nil {
+				return 0, err
+			}
+			if len(columns) != 1 {
+				return 0, ErrSubqueryColumns
+			}
+			if _, err := unifyOperands(typ, columns[0].Type); err != nil {
+				return 0, err
+			}
+		}
+		for _, e := range exp.in.list {
+			eType, err := mb.expressionType(sc, e)
+			if err != nil {
+				return 0, err
+			}
+			if typ, err = unifyOperands(typ, eType); err != nil {
+				return 0, err
+			}
+		}
+	case isNullKind:
+		if _, err := mb.expressionType(sc, exp.isNull.operand); err != nil {
+			return 0, err
+		}
+	case unaryKind:
+		if err := mb.checkBoolean(sc, exp.unary.operand); err != nil {
+			return 0, err
+		}
+	case binaryKind:
+		if exp.binary.op.kind == KEYWORD {
+			if err := mb.checkBoolean(sc, exp.binary.a); err != nil {
+				return 0, err
+			}
+			if err := mb.checkBoolean(sc, exp.binary.b); err != nil {
+				return 0, err
+			}
+			break
+		}
+
+		aType, err := mb.expressionType(sc, exp.binary.a)
+		if err != nil {
+			return 0, err
+		}
+		bType, err := mb.expressionType(sc, exp.binary.b)
+		if err != nil {
+			return 0, err
+		}
+		// string literals are read as the type they are compared with, and
+		// must be values of it
+		if isStringLiteral(exp.binary.a) {
+			if _, aType, err = literalOperand(exp.binary.a, nil, aType, bType); err != nil {
+				return 0, err
+			}
+		}
+		if isStringLiteral(exp.binary.b) {
+			if _, bType, err = literalOperand(exp.binary.b, nil, bType, aType); err != nil {
+				return 0, err
+			}
+		}
+		if _, err := unifyOperands(aType, bType); err != nil {
+			return 0, err
+		}
+	}
+	return BoolType, nil
+}
+
+// checkBoolean checks that the operand of a logical operator is a boolean
+func (mb *MemoryBackend) checkBoolean(sc *scope, exp *expression) error {
+	typ, err := mb.expressionType(sc, exp)
+	if err != nil {
+		return err
+	}
+	if typ != BoolType && typ != nullType {
+		return fmt.Errorf("%w: %s", ErrInvalidPredicate, exp.generateCode())
+	}
+	return nil
+}
+
+// functionType checks the arguments of a function call, returning the type
+// of its result
+func (mb *MemoryBackend) functionType(sc *scope, fn *functionCall) (ColumnType, error) {
+	name := fn.name.value
+	if !isAggregate(fn) {
+		return mb.scalarFunctionType(sc, fn)
+	}
+
+	if fn.asterisk {
+		if name != "count" {
+			return 0, fmt.Errorf("%w: %s(*)", ErrInvalidArguments, name)
+		}
+		return IntType, nil
+	}
+	if len(fn.args) != 1 {
+		return 0, fmt.Errorf("%w: %s takes exactly one argument", ErrInvalidArguments, name)
+	}
+
+	typ, err := mb.expressionType(sc, fn.args[0])
+	if err != nil {
+		return 0, err
+	}
+	switch name {
+	case "count":
+		return IntType, nil
+	case "sum", "avg":
+		if !isNumericType(typ) {
+			return 0, fmt.Errorf("%w: %s of a non-numeric value", ErrInvalidArguments, name)
+		}
+		// averages are fractional even of integers, and exact of numerics
+		if name == "avg" && typ != NumericType {
+			return FloatType, nil
+		}
+	}
+	return typ, nil
+}
+
+// evaluateCell evaluates a value expression in the scope, predicates being
+// booleans, NULL when unknown
+func (mb *MemoryBackend) evaluateCell(sc *scope, exp *expression) (MemoryCell, ColumnType, error) {
+	if isPredicate(exp) {
+		t, err := mb.evaluateTruth(sc, exp)
+		if err != nil || t == truthUnknown {
+			return nil, BoolType, err
+		}
+		return boolCell(t == truthTrue), BoolType, nil
+	}
+
+	switch exp.kind {
+	case literalKind:
+		lit := exp.literal
+		switch lit.kind {
+		case IDENTIFIER:
+			owner, i, err := sc.lookup(exp.qualifier, lit.value)
+			if err != nil {
+				return nil, 0, err
+			}
+			return owner.row[i], owner.relation.columns[i].typ, nil
+		case NUMERIC, STRING, HEXSTRING:
+			if exp.typeName != nil {
+				typ, err := columnType(*exp.typeName)
+				if err != nil {
+					return nil, 0, err
+				}
+				c, err := parseCell(lit.value, typ)
+				return c, typ, err
+			}
+			return mb.tokenToCell(lit)
+		case KEYWORD:
+			switch keyword(lit.value) {
+			case NULL:
+				return nil, nullType, nil
+			case TRUE, FALSE:
+				return boolCell(keyword(lit.value) == TRUE), BoolType, nil
+			}
+		}
+	case unaryKind:
+		c, typ, err := mb.evaluateCell(sc, exp.unary.operand)
+		if err != nil {
+			return nil, 0, err
+		}
+		if exp.unary.op.kind == SYMBOL && typ == IntervalType {
+			if c == nil || symbol(exp.unary.op.value) != MINUS {
+				return c, typ, nil
+			}
+			iv, err := c.interval().negate()
+			if err != nil {
+				return nil, 0, err
+			}
+			return intervalCell(iv), typ, nil
+		}
+		if exp.unary.op.kind != SYMBOL || !isNumericType(typ) {
+			return nil, 0, ErrInvalidOperands
+		}
+		typ = arithmeticType(typ, typ)
+		if c == nil || symbol(exp.unary.op.value) != MINUS {
+			return c, typ, nil
+		}
+		switch typ {
+		case FloatType:
+			return floatCell(-c.float()), typ, nil
+		case NumericType:
+			d := c.decimal()
+			return numericCell(Decimal{Unscaled: d.Unscaled.Neg(d.Unscaled), Scale: d.Scale}), typ, nil
+		}
+		return intResult(-c.int(), c.int() != math.MinInt64)
+	case binaryKind:
+		bin := exp.binary
+		a, aType, err := mb.evaluateCell(sc, bin.a)
+		if err != nil {
+			return nil, 0, err
+		}
+		b, bType, err := mb.evaluateCell(sc, bin.b)
+		if err != nil {
+			return nil, 0, err
+		}
+		return evaluateArithmetic(bin.op, a, aType, b, bType)
+	case functionKind:
+		if !isAggregate(exp.function) {
+			return mb.evaluateScalarFunction(sc, exp.function)
+		}
+		return mb.evaluateAggregate(sc, exp.function)
+	case subqueryKind:
+		results, err := mb.query(exp.subquery, sc)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(results.Columns) != 1 {
+			return nil, 0, ErrSubqueryColumns
+		}
+		if len(results.Row) > 1 {
+			return nil, 0, ErrSubqueryRows
+		}
+		// a subquery returning no rows is NULL
+		if len(results.Row) == 0 {
+			return nil, results.Columns[0].Type, nil
+		}
+		return results.Row[0][0].(MemoryCell), results.Columns[0].Type, nil
+	}
+	return nil, 0, ErrInvalidOperands
+}
+
+// aggregateFunctions are computed over all the rows of a group
+var aggregateFunctions = []string{"count", "sum", "avg", "min", "max"}
+
+func isAggregate(fn *functionCall) bool {
+	for _, name := range aggregateFunctions {
+		if fn.name.value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAggregate reports whether the expression calls an aggregate function,
+// those of subqueries aggregating over the subquery instead
+func hasAggregate(exp *expression) bool {
+	switch exp.kind {
+	case inKind:
+		if hasAggregate(exp.in.operand) {
+			return true
+		}
+		for _, e := range exp.in.list {
+			if hasAggregate(e) {
+				return true
+			}
+		}
+	case isNullKind:
+		return hasAggregate(exp.isNull.operand)
+	case unaryKind:
+		return hasAggregate(exp.unary.operand)
+	case binaryKind:
+		return hasAggregate(exp.binary.a) || hasAggregate(exp.binary.b)
+	case functionKind:
+		if isAggregate(exp.function) {
+			return true
+		}
+		for _, arg := range exp.function.args {
+			if hasAggregate(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evaluateAggregate computes an aggregate function over the rows of the
+// group in scope, NULLs are ignored by every aggregate but COUNT(*)
+func (mb *MemoryBackend) evaluateAggregate(sc *scope, fn *functionCall) (MemoryCell, ColumnType, error) {
+	name := fn.name.value
+	if !sc.aggregating {
+		return nil, 0, fmt.Errorf("%w: %s", ErrInvalidAggregate, name)
+	}
+
+	typ, err := mb.functionType(sc, fn)
+	if err != nil {
+		return nil, 0, err
+	}
+	if fn.asterisk {
+		return intCell(int64(len(sc.group))), IntType, nil
+	}
+
+	var values []MemoryCell
+	var argType ColumnType
+	seen := map[string]bool{}
+	for _, row := range sc.group {
+		// aggregates cannot be nested, so the argument is evaluated in a
+		// scope that is not aggregating
+		c, cType, err := mb.evaluateCell(&scope{relation: sc.relation, row: row, outer: sc.outer}, fn.args[0])
+		if err != nil {
+			return nil, 0, err
+		}
+		if c == nil {
+			continue
+		}
+		argType = cType
+		if fn.distinct {
+			key := string(appendGroupKey(nil, c, cType))
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		values = append(values, c)
+	}
+
+	if name == "count" {
+		return intCell(int64(len(values))), IntType, nil
+	}
+	// every other aggregate of no values is NULL
+	if len(values) == 0 {
+		return nil, typ, nil
+	}
+
+	switch name {
+	case "sum", "avg":
+		if typ == NumericType {
+			return numericAggregate(name, values), typ, nil
+		}
+	}
+
+	switch name {
+	case "sum":
+		if typ == FloatType {
+			var sum float64
+			for _, c := range values {
+				sum += c.float()
+			}
+			return floatCell(sum), typ, nil
+		}
+		// partial sums may be out of range as long as the total is not
+		sum := new(big.Int)
+		for _, c := range values {
+			sum.Add(sum, big.NewInt(c.int()))
+		}
+		if !sum.IsInt64() {
+			return nil, 0, ErrIntegerOutOfRange
+		}
+		return intCell(sum.Int64()), typ, nil
+	case "avg":
+		var sum float64
+		for _, c := range values {
+			sum += convertCell(c, argType, FloatType).float()
+		}
+		return floatCell(sum / float64(len(values))), typ, nil
+	case "min", "max":
+		result := values[0]
+		for _, c := range values[1:] {
+			cmp := compareCells(c, result, typ)
+			if (name == "min" && cmp < 0) || (name == "max" && cmp > 0) {
+				result = c
+			}
+		}
+		return result, typ, nil
+	}
+	return nil, 0, fmt.Errorf("%w: %s", ErrFunctionDoesNotExist, name)
+}
+
+// evaluateArithmetic applies an arithmetic or concatenation operator
+func evaluateArithmetic(op token, a MemoryCell, aType ColumnType, b MemoryCell, bType ColumnType) (MemoryCell, ColumnType, error) {
+	if op.kind != SYMBOL {
+		return nil, 0, ErrInvalidOperands
+	}
+
+	// operations on NULL are NULL
+	if symbol(op.value) == CONCAT {
+		typ := concatType(aType, bType)
+		if a == nil || b == nil {
+			return nil, typ, nil
+		}
+		if typ == BlobType {
+			return blobCell(append(append([]byte{}, a...), b...)), typ, nil
+		}
+		return textCell(cellText(a, aType) + cellText(b, bType)), typ, nil
+	}
+	if isTemporalType(aType) || isTemporalType(bType) {
+		return evaluateTemporalArithmetic(op, a, aType, b, bType)
+	}
+
+	if !isNumericType(aType) || !isNumericType(bType) {
+		return nil, 0, ErrInvalidOperands
+	}
+	typ := arithmeticType(aType, bType)
+	if a == nil || b == nil {
+		return nil, typ, nil
+	}
+	switch typ {
+	case FloatType:
+		return evaluateFloatArithmetic(op, convertCell(a, aType, typ).float(), convertCell(b, bType, typ).float())
+	case NumericType:
+		return evaluateNumericArithmetic(op, convertCell(a, aType, typ).decimal(), convertCell(b, bType, typ).decimal())
+	}
+	ai, bi := a.int(), b.int()
+
+	// results that wrap around are out of range
+	switch symbol(op.value) {
+	case PLUS:
+		r := ai + bi
+		return intResult(r, (r > ai) == (bi > 0))
+	case MINUS:
+		r := ai - bi
+		return intResult(r, (r < ai) == (bi > 0))
+	case ASTERISK:
+		r := ai * bi
+		return intResult(r, ai == 0 || (r/ai == bi && !(ai == -1 && bi == math.MinInt64)))
+	case SLASH:
+		if bi == 0 {
+			return nil, 0, ErrDivisionByZero
+		}
+		return intResult(ai/bi, !(ai == math.MinInt64 && bi == -1))
+	case PERCENT:
+		if bi == 0 {
+			return nil, 0, ErrDivisionByZero
+		}
+		return intCell(ai % bi), IntType, nil
+	}
+	return nil, 0, ErrInvalidOperands
+}
+
+// intResult returns the result of integer arithmetic, failing unless it is
+// exact
+func intResult(i int64, exact bool) (MemoryCell, ColumnType, error) {
+	if !exact {
+		return nil, 0, ErrIntegerOutOfRange
+	}
+	return intCell(i), IntType, nil
+}
+
+// evaluateFloatArithmetic applies an arithmetic operator to floats, which
+// have no remainder
+func evaluateFloatArithmetic(op token, a, b float64) (MemoryCell, ColumnType, error) {
+	switch symbol(op.value) {
+	case PLUS:
+		return floatCell(a + b), FloatType, nil
+	case MINUS:
+		return floatCell(a - b), FloatType, nil
+	case ASTERISK:
+		return floatCell(a * b), FloatType, nil
+	case SLASH:
+		if b == 0 {
+			return nil, 0, ErrDivisionByZero
+		}
+		return floatCell(a / b), FloatType, nil
+	}
+	return nil, 0, ErrInvalidOperands
+}
+
+// truth is the value of a predicate in SQL's three-valued logic, where
+// comparing with NULL is unknown
+type truth uint
+
+const (
+	truthFalse truth = iota
+	truthTrue
+	truthUnknown
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+func (t truth) not() truth {
+	switch t {
+	case truthFalse:
+		return truthTrue
+	case truthTrue:
+		return truthFalse
+	}
+	return truthUnknown
+}
+
+// evaluatePredicate evaluates a boolean expression in the scope, reporting
+// whether it is true. Rows for which it is unknown are filtered out like
+// those for which it is false.
+func (mb *MemoryBackend) evaluatePredicate(sc *scope, exp *expression) (bool, error) {
+	t, err := mb.evaluateTruth(sc, exp)
+	return t == truthTrue, err
+}
+
+// evaluateTruth evaluates a boolean expression in the scope
+func (mb *MemoryBackend) evaluateTruth(sc *scope, exp *expression) (truth, error) {
+	// values used as predicates must be booleans
+	if !isPredicate(exp) {
+		c, typ, err := mb.evaluateCell(sc, exp)
+		if err != nil {
+			return truthFalse, err
+		}
+		if typ != BoolType && typ != nullType {
+			return truthFalse, fmt.Errorf("%w: %s", ErrInvalidPredicate, exp.generateCode())
+		}
+		if c == nil {
+			return truthUnknown, nil
+		}
+		return truthOf(c.bool()), nil
+	}
+
+	switch exp.kind {
+	case existsKind:
+		results, err := mb.query(exp.subquery, sc)
+		if err != nil {
+			return truthFalse, err
+		}
+		return truthOf(len(results.Row) > 0), nil
+	case inKind:
+		t, err := mb.evaluateIn(sc, exp.in)
+		if err != nil {
+			return truthFalse, err
+		}
+		if exp.in.not {
+			return t.not(), nil
+		}
+		return t, nil
+	case isNullKind:
+		c, _, err := mb.evaluateCell(sc, exp.isNull.operand)
+		if err != nil {
+			return truthFalse, err
+		}
+		return truthOf((c == nil) != exp.isNull.not), nil
+	case unaryKind:
+		// NOT is the only unary predicate
+		if exp.unary.op.kind != KEYWORD {
+			return truthFalse, ErrInvalidPredicate
+		}
+		t, err := mb.evaluateTruth(sc, exp.unary.operand)
+		if err != nil {
+			return truthFalse, err
+		}
+		return t.not(), nil
+	case binaryKind:
+		bin := exp.binary
+		if bin.op.kind == KEYWORD {
+			a, err := mb.evaluateTruth(sc, bin.a)
+			if err != nil {
+				return truthFalse, err
+			}
+			switch keyword(bin.op.value) {
+			case AND:
+				if a == truthFalse {
+					return truthFalse, nil
+				}
+			case OR:
+				if a == truthTrue {
+					return truthTrue, nil
+				}
+			default:
+				return truthFalse, ErrInvalidPredicate
+			}
+
+			// the result is that of b, unless only one of them is unknown
+			b, err := mb.evaluateTruth(sc, bin.b)
+			if err != nil {
+				return truthFalse, err
+			}
+			if a == truthUnknown && (b == truthTrue) == (keyword(bin.op.value) == AND) {
+				return truthUnknown, nil
+			}
+			return b, nil
+		}
+
+		a, aType, err := mb.evaluateCell(sc, bin.a)
+		if err != nil {
+			return truthFalse, err
+		}
+		b, bType, err := mb.evaluateCell(sc, bin.b)
+		if err != nil {
+			return truthFalse, err
+		}
+		if a, aType, err = literalOperand(bin.a, a, aType, bType); err != nil {
+			return truthFalse, err
+		}
+		if b, bType, err = literalOperand(bin.b, b, bType, aType); err != nil {
+			return truthFalse, err
+		}
+		typ, ok := unifyTypes(aType, bType)
+		if !ok {
+			return truthFalse, ErrInvalidOperands
+		}
+		if a == nil || b == nil {
+			return truthUnknown, nil
+		}
+
+		cmp := compareCells(convertCell(a, aType, typ), convertCell(b, bType, typ), typ)
+		switch symbol(bin.op.value) {
+		case EQUALS:
+			return truthOf(cmp == 0), nil
+		case NOTEQUALS:
+			return truthOf(cmp != 0), nil
+		case LESSTHAN:
+			return truthOf(cmp < 0), nil
+		case LESSTHANOREQUAL:
+			return truthOf(cmp <= 0), nil
+		case GREATERTHAN:
+			return truthOf(cmp > 0), nil
+		case GREATERTHANOREQUAL:
+			return truthOf(cmp >= 0), nil
+		}
+	}
+	return truthFalse, ErrInvalidPredicate
+}
+
+// evaluateIn tests whether the operand equals any of the values of the list
+// or rows of the subquery. Without a match, the test is unknown if the
+// operand or any of the values is NULL.
+func (mb *MemoryBackend) evaluateIn(sc *scope, in *inExpression) (truth, error) {
+	c, cType, err := mb.evaluateCell(sc, in.operand)
+	if err != nil {
+		return truthFalse, err
+	}
+
+	typ := cType
+	var values []MemoryCell
+	var types []ColumnType
+	if in.subquery != nil {
+		results, err := mb.query(in.subquery, sc)
+		if err != nil {
+			return truthFalse, err
+		}
+		if len(results.Columns) != 1 {
+			return truthFalse, ErrSubqueryColumns
+		}
+		if typ, err = unifyOperands(typ, results.Columns[0].Type); err != nil {
+			return truthFalse, err
+		}
+		for _, row := range results.Row {
+			values = append(values, row[0].(MemoryCell))
+			types = append(types, results.Columns[0].Type)
+		}
+	} else {
+		for _, exp := range in.list {
+			v, vType, err := mb.evaluateCell(sc, exp)
+			if err != nil {
+				return truthFalse, err
+			}
+			if typ, err = unifyOperands(typ, vType); err != nil {
+				return truthFalse, err
+			}
+			values = append(values, v)
+			types = append(types, vType)
+		}
+	}
+
+	if len(values) == 0 {
+		return truthFalse, nil
+	}
+	result := truthFalse
+	if c == nil {
+		result = truthUnknown
+	}
+	c = convertCell(c, cType, typ)
+	for i, v := range values {
+		if v == nil {
+			result = truthUnknown
+		} else if c != nil && compareCells(c, convertCell(v, types[i], typ), typ) == 0 {
+			return truthTrue, nil
+		}
+	}
+	return result, nil
+}
+
+// unifyOperands is unifyTypes, failing with ErrInvalidOperands
+func unifyOperands(a, b ColumnType) (ColumnType, error) {
+	typ, ok := unifyTypes(a, b)
+	if !ok {
+		return 0, ErrInvalidOperands
+	}
+	return typ, nil
+}
+
+// compareCells returns -1, 0 or 1 if a is less than, equal to or greater
+// than b, both being cells of the type typ
+func compareCells(a, b MemoryCell, typ ColumnType) int {
+	switch typ {
+	case IntType:
+		return compareInts(a.int(), b.int())
+	case FloatType:
+		af, bf := a.float(), b.float()
+		if af < bf {
+			return -1
+		}
+		if af > bf {
+			return 1
+		}
+		return 0
+	case DateType:
+		return compareInts(int64(a.date()), int64(b.date()))
+	case TimeType, TimestampType, TimestampTzType:
+		return compareInts(a.int(), b.int())
+	case IntervalType:
+		aDays, aUsec := a.interval().span()
+		bDays, bUsec := b.interval().span()
+		if cmp := compareInts(aDays, bDays); cmp != 0 {
+			return cmp
+		}
+		return compareInts(aUsec, bUsec)
+	case NumericType:
+		return a.decimal().Rat().Cmp(b.decimal().Rat())
+	}
+	return bytes.Compare(a, b)
+}
+
+func compareInts(a, b int64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// expandSelectItems resolves the select list into one expression per output
+// column, replacing asterisks with references to every column of the
+// relation, or of one of its tables
+func (mb *MemoryBackend) expandSelectItems(r *relation, slct *SelectStatement) ([]*selectItem, error) {
+	var items []*selectItem
+	for _, item := range slct.item {
+		if !item.asterisk {
+			items = append(items, item)
+			continue
+		}
+
+		if slct.from == nil {
+			return nil, ErrInvalidSelectItem
+		}
+
+		found := false
+		for _, col := range r.columns {
+			if item.table != nil {
+				if col.table != item.table.value {
+					continue
+				}
+			} else if col.using {
+				continue
+			}
+			found = true
+
+			exp := &expression{
+				literal: &token{
+					value: col.name,
+					kind:  IDENTIFIER,
+				},
+				kind: literalKind,
+			}
+			if col.table != "" {
+				exp.qualifier = &token{
+					value: col.table,
+					kind:  IDENTIFIER,
+				}
+			}
+			items = append(items, &selectItem{exp: exp})
+		}
+		if item.table != nil && !found {
+			return nil, fmt.Errorf("%w: %s", ErrTableDoesNotExist, item.table.value)
+		}
+	}
+	return items, nil
+}
+
+// evaluateLimit evaluates a LIMIT or OFFSET expression, returning def when
+// the clause is absent or NULL
+func (mb *MemoryBackend) evaluateLimit(exp *expression, def int) (int, error) {
+	if exp == nil {
+		return def, nil
+	}
+
+	c, typ, err := mb.evaluateCell(&scope{}, exp)
+	if err != nil {
+		return 0, err
+	}
+	if isIntType(typ) && c == nil {
+		return def, nil
+	}
+	if typ != IntType || c.int() < 0 {
+		return 0, ErrInvalidLimit
+	}
+	return int(c.int()), nil
+}
+
+// sortKey is a resolved ORDER BY item, sorting either on an output column or
+// on an expression over the input row
+type sortKey struct {
+	column     int
+	exp        *expression
+	typ        ColumnType
+	desc       bool
+	nullsFirst bool
+}
+
+// resolveSortKeys binds the ORDER BY items to output columns, either by their
+// 1-based position or by name, falling back to expressions over the relation
+func (mb *MemoryBackend) resolveSortKeys(sc *scope, orderBy []*orderByItem, items []*selectItem, columns []ResultColumn) ([]sortKey, error) {
+	var keys []sortKey
+	for _, item := range orderBy {
+		key := sortKey{
+			column:     -1,
+			exp:        item.exp,
+			desc:       item.desc,
+			nullsFirst: item.nullsFirst,
+		}
+
+		if item.exp.kind == literalKind {
+			lit := item.exp.literal
+			switch lit.kind {
+			case NUMERIC:
+				position, err := strconv.Atoi(lit.value)
+				if err != nil || position < 1 || position > len(columns) {
+					return nil, fmt.Errorf("%w: position %s is not in select list", ErrInvalidSortKey, lit.value)
+				}
+				key.column = position - 1
+			case IDENTIFIER:
+				for i, item := range items {
+					if item.name() == lit.value {
+						key.column = i
+						break
+					}
+				}
+			}
+		}
+
+		// expressions repeated from the select list are read from the output
+		if key.column < 0 {
+			code := item.exp.generateCode()
+			for i, item := range items {
+				if item.exp.generateCode() == code {
+					key.column = i
+					break
+				}
+			}
+		}
+
+		if key.column >= 0 {
+			key.typ = columns[key.column].Type
+		} else {
+			typ, err := mb.expressionType(sc, item.exp)
+			if err != nil {
+				return nil, err
+			}
+			key.typ = typ
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// sortableResults sorts result rows by the values of their sort keys
+type sortableResults struct {
+	rows   [][]Cell
+	values [][]MemoryCell
+	keys   []sortKey
+}
+
+func (sr *sortableResults) Len() int {
+	return len(sr.rows)
+}
+
+func (sr *sortableResults) Swap(i, j int) {
+	sr.rows[i], sr.rows[j] = sr.rows[j], sr.rows[i]
+	sr.values[i], sr.values[j] = sr.values[j], sr.values[i]
+}
+
+func (sr *sortableResults) Less(i, j int) bool {
+	for k, key := range sr.keys {
+		a, b := sr.values[i][k], sr.values[j][k]
+
+		var cmp int
+		switch {
+		case a == nil && b == nil:
+			cmp = 0
+		case a == nil || b == nil:
+			// NULLs are placed regardless of the direction
+			if (a == nil) == key.nullsFirst {
+				return true
+			}
+			return false
+		default:
+			cmp = compareCells(a, b, key.typ)
+			if key.desc {
+				cmp = -cmp
+			}
+		}
+
+		if cmp != 0 {
+			return cmp < 0
+		}
+	}
+	return false
+}
+
+// isAggregating reports whether the select collapses rows into groups
+func isAggregating(slct *SelectStatement, items []*selectItem, keys []sortKey) bool {
+	if len(slct.groupBy) > 0 || slct.having != nil {
+		return true
+	}
+	for _, item := range items {
+		if hasAggregate(item.exp) {
+			return true
+		}
+	}
+	for _, key := range keys {
+		if key.column < 0 && hasAggregate(key.exp) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGrouped verifies that the select list, HAVING and ORDER BY of an
+// aggregating select only read columns through GROUP BY expressions or
+// aggregate functions, subqueries included
+func (mb *MemoryBackend) checkGrouped(r *relation, slct *SelectStatement, items []*selectItem, keys []sortKey, outer *scope) error {
+	var exps []*expression
+	for _, item := range items {
+		exps = append(exps, item.exp)
+	}
+	if slct.having != nil {
+		exps = append(exps, slct.having)
+	}
+	for _, key := range keys {
+		if key.column < 0 {
+			exps = append(exps, key.exp)
+		}
+	}
+
+	sc := &scope{relation: r, outer: outer}
+	for _, exp := range exps {
+		if err := mb.checkGroupedExpression(sc, r, exp, slct.groupBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkGroupedExpression checks an expression resolved in the scope sc,
+// either that of the grouped relation r or of a subquery nested in it.
+// Subqueries are evaluated once per group, so within them the columns of r
+// can only be read directly when grouped, even by aggregate functions.
+func (mb *MemoryBackend) checkGroupedExpression(sc *scope, r *relation, exp *expression, groupBy []*expression) error {
+	nested := sc.relation != r
+	code := exp.generateCode()
+	if !nested {
+		for _, g := range groupBy {
+			if g.generateCode() == code {
+				return nil
+			}
+		}
+	}
+
+	switch exp.kind {
+	case subqueryKind, existsKind:
+		return mb.checkGroupedSubquery(sc, r, exp.subquery, groupBy)
+	case inKind:
+		if err := mb.checkGroupedExpression(sc, r, exp.in.operand, groupBy); err != nil {
+			return err
+		}
+		for _, e := range exp.in.list {
+			if err := mb.checkGroupedExpression(sc, r, e, groupBy); err != nil {
+				return err
+			}
+		}
+		if exp.in.subquery != nil {
+			return mb.checkGroupedSubquery(sc, r, exp.in.subquery, groupBy)
+		}
+	case literalKind:
+		if exp.literal.kind != IDENTIFIER {
+			return nil
+		}
+		// the same column may be referenced qualified or not, columns of
+		// subqueries and enclosing queries are constant over the group
+		owner, i, err := sc.lookup(exp.qualifier, exp.literal.value)
+		if err != nil || owner.relation != r {
+			return nil
+		}
+		for _, g := range groupBy {
+			if g.kind != literalKind || g.literal.kind != IDENTIFIER {
+				continue
+			}
+			if j, err := r.columnIndex(g.qualifier, g.literal.value); err == nil && i == j {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrColumnNotGrouped, code)
+	case isNullKind:
+		return mb.checkGroupedExpression(sc, r, exp.isNull.operand, groupBy)
+	case unaryKind:
+		return mb.checkGroupedExpression(sc, r, exp.unary.operand, groupBy)
+	case binaryKind:
+		if err := mb.checkGroupedExpression(sc, r, exp.binary.a, groupBy); err != nil {
+			return err
+		}
+		return mb.checkGroupedExpression(sc, r, exp.binary.b, groupBy)
+	case functionKind:
+		if isAggregate(exp.function) && !nested {
+			return nil
+		}
+		for _, arg := range exp.function.args {
+			if err := mb.checkGroupedExpression(sc, r, arg, groupBy); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkGroupedSubquery checks the expressions of a subquery nested in the
+// scope sc against the grouping of the relation r
+func (mb *MemoryBackend) checkGroupedSubquery(sc *scope, r *relation, slct *SelectStatement, groupBy []*expression) error {
+	if slct.setOp != nil {
+		if err := mb.checkGroupedSubquery(sc, r, slct.setOp.left, groupBy); err != nil {
+			return err
+		}
+		return mb.checkGroupedSubquery(sc, r, slct.setOp.right, groupBy)
+	}
+
+	inner := &scope{relation: &relation{}, outer: sc}
+	if slct.from != nil {
+		if err := mb.checkGroupedFrom(sc, r, slct.from, groupBy); err != nil {
+			return err
+		}
+		rel, err := mb.relationColumns(slct.from, sc)
+		if err != nil {
+			return err
+		}
+		inner.relation = rel
+	}
+
+	exps := append([]*expression{slct.where, slct.having}, slct.groupBy...)
+	for _, item := range slct.item {
+		exps = append(exps, item.exp)
+	}
+	for _, item := range slct.orderBy {
+		exps = append(exps, item.exp)
+	}
+	for _, exp := range exps {
+		if exp == nil {
+			continue
+		}
+		if err := mb.checkGroupedExpression(inner, r, exp, groupBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkGroupedFrom checks the derived tables and join conditions of the FROM
+// item of a subquery nested in the scope sc
+func (mb *MemoryBackend) checkGroupedFrom(sc *scope, r *relation, from *fromItem, groupBy []*expression) error {
+	switch from.kind {
+	case subqueryFromKind:
+		return mb.checkGroupedSubquery(sc, r, from.subquery, groupBy)
+	case joinFromKind:
+		if err := mb.checkGroupedFrom(sc, r, from.join.left, groupBy); err != nil {
+			return err
+		}
+		if err := mb.checkGroupedFrom(sc, r, from.join.right, groupBy); err != nil {
+			return err
+		}
+		if from.join.on == nil {
+			return nil
+		}
+		rel, err := mb.relationColumns(from, sc)
+		if err != nil {
+			return err
+		}
+		return mb.checkGroupedExpression(&scope{relation: rel, outer: sc}, r, from.join.on, groupBy)
+	}
+	return nil
+}
+
+// scopeIterator yields the scopes the select list is evaluated in, nil once
+// there are no more
+type scopeIterator func() (*scope, error)
+
+// filterRows iterates over the rows of the relation matching the predicate
+func (mb *MemoryBackend) filterRows(r *relation, where *expression, outer *scope) scopeIterator {
+	i := 0
+	return func() (*scope, error) {
+		for i < len(r.rows) {
+			sc := &scope{
+				relation: r,
+				row:      r.rows[i],
+				outer:    outer,
+			}
+			i++
+
+			if where != nil {
+				ok, err := mb.evaluatePredicate(sc, where)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+			}
+			return sc, nil
+		}
+		return nil, nil
+	}
+}
+
+// groupRows hashes the rows by their GROUP BY values, iterating over the
+// groups matching the HAVING predicate. Without GROUP BY all the rows form a
+// single group, even when there are none.
+func (mb *MemoryBackend) groupRows(r *relation, next scopeIterator, slct *SelectStatement, outer *scope) (scopeIterator, error) {
+	var groups []*scope
+	indexes := map[string]int{}
+	if len(slct.groupBy) == 0 {
+		indexes[""] = 0
+		groups = append(groups, &scope{
+			relation:    r,
+			aggregating: true,
+			outer:       outer,
+		})
+	}
+
+	for {
+		sc, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if sc == nil {
+			break
+		}
+
+		var key []byte
+		for _, exp := range slct.groupBy {
+			c, typ, err := mb.evaluateCell(sc, exp)
+			if err != nil {
+				return nil, err
+			}
+			key = appendGroupKey(key, c, typ)
+		}
+
+		i, ok := indexes[string(key)]
+		if !ok {
+			i = len(groups)
+			indexes[string(key)] = i
+			groups = append(groups, &scope{
+				relation:    r,
+				row:         sc.row,
+				aggregating: true,
+				outer:       outer,
+			})
+		}
+		// the first row of the group stands in for it when evaluating the
+		// grouped columns
+		if groups[i].row == nil {
+			groups[i].row = sc.row
+		}
+		groups[i].group = append(groups[i].group, sc.row)
+	}
+
+	i := 0
+	return func() (*scope, error) {
+		for i < len(groups) {
+			sc := groups[i]
+			i++
+
+			if slct.having != nil {
+				ok, err := mb.evaluatePredicate(sc, slct.having)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+			}
+			return sc, nil
+		}
+		return nil, nil
+	}, nil
+}
+
+// appendGroupKey appends a length prefixed cell of the type typ to a
+// grouping key, so that keys of distinct values never collide and those of
+// values compareCells finds equal are equal: numerics are normalized, as 1.5
+// and 1.50 are equal, and intervals are keyed by their span, as 1 month and
+// 30 days are.
+func appendGroupKey(key []byte, c MemoryCell, typ ColumnType) []byte {
+	if c == nil {
+		return append(key, 0)
+	}
+	switch typ {
+	case NumericType:
+		c = numericCell(c.decimal().normalize())
+	case IntervalType:
+		days, usec := c.interval().span()
+		c = append(intCell(days), intCell(usec)...)
+	}
+	key = append(key, 1)
+	key = append(key, intCell(int64(len(c)))...)
+	return append(key, c...)
+}
+
+// rowKey encodes a result row with the columns so that rows are equal
+// exactly when their keys are, NULLs being equal to each other
+func rowKey(row []Cell, columns []ResultColumn) string {
+	var key []byte
+	for i, c := range row {
+		key = appendGroupKey(key, c.(MemoryCell), columns[i].Type)
+	}
+	return string(key)
+}
+
+// sortAndLimit orders the rows by the values of their sort keys and applies
+// OFFSET and LIMIT, a negative limit meaning no limit
+func sortAndLimit(rows [][]Cell, values [][]MemoryCell, keys []sortKey, offset, limit int) [][]Cell {
+	if len(keys) > 0 {
+		sort.Stable(&sortableResults{
+			rows:   rows,
+			values: values,
+			keys:   keys,
+		})
+	}
+
+	if offset >= len(rows) {
+		return nil
+	}
+	rows = rows[offset:]
+	if limit >= 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// selectSetOperation evaluates both sides of a set operation, combining
+// their rows before ordering and limiting them
+func (mb *MemoryBackend) selectSetOperation(slct *SelectStatement, outer *scope) (*Results, error) {
+	setOp := slct.setOp
+	left, err := mb.query(setOp.left, outer)
+	if err != nil {
+		return nil, err
+	}
+	right, err := mb.query(setOp.right, outer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(left.Columns) != len(right.Columns) {
+		return nil, fmt.Errorf("%w: %d and %d columns", ErrColumnCountMismatch, len(left.Columns), len(right.Columns))
+	}
+	columns, err := unifyColumns(left.Columns, right.Columns)
+	if err != nil {
+		return nil, err
+	}
+	left.Row = convertRows(left.Row, left.Columns, columns)
+	right.Row = convertRows(right.Row, right.Columns, columns)
+
+	// the number of times each row occurs on the right
+	counts := map[string]int{}
+	for _, row := range right.Row {
+		counts[rowKey(row, columns)]++
+	}
+
+	var rows [][]Cell
+	seen := map[string]bool{}
+	emit := func(row []Cell, key string) {
+		if setOp.all || !seen[key] {
+			rows = append(rows, row)
+		}
+		seen[key] = true
+	}
+
+	switch keyword(setOp.op.value) {
+	case UNION:
+		for _, row := range append(left.Row, right.Row...) {
+			emit(row, rowKey(row, columns))
+		}
+	case INTERSECT:
+		for _, row := range left.Row {
+			key := rowKey(row, columns)
+			if counts[key] > 0 {
+				emit(row, key)
+				counts[key]--
+			}
+		}
+	case EXCEPT:
+		for _, row := range left.Row {
+			key := rowKey(row, columns)
+			if counts[key] > 0 {
+				// EXCEPT ALL removes one occurrence per row on the right,
+				// EXCEPT every occurrence
+				if setOp.all {
+					counts[key]--
+				}
+				continue
+			}
+			emit(row, key)
+		}
+	}
+
+	// the sort keys of a set operation can only refer to its output columns
+	var items []*selectItem
+	for _, col := range left.Columns {
+		items = append(items, &selectItem{
+			exp: &expression{
+				literal: &token{
+					value: col.Name,
+					kind:  IDENTIFIER,
+				},
+				kind: literalKind,
+			},
+		})
+	}
+	keys, err := mb.resolveSortKeys(&scope{}, slct.orderBy, items, columns)
+	if err != nil {
+		return nil, err
+	}
+	var values [][]MemoryCell
+	for _, row := range rows {
+		var value []MemoryCell
+		for _, key := range keys {
+			if key.column < 0 {
+				return nil, fmt.Errorf("%w: %s is not an output column", ErrInvalidSortKey, key.exp.generateCode())
+			}
+			value = append(value, row[key.column].(MemoryCell))
+		}
+		values = append(values, value)
+	}
+
+	offset, err := mb.evaluateLimit(slct.offset, 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := mb.evaluateLimit(slct.limit, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Results{
+		Columns: columns,
+		Row:     sortAndLimit(rows, values, keys, offset, limit),
+	}, nil
+}
+
+// unifyColumns returns the columns of a set operation, typed as both sides
+// can be combined
+func unifyColumns(left, right []ResultColumn) ([]ResultColumn, error) {
+	if len(left) != len(right) {
+		return nil, fmt.Errorf("%w: %d and %d columns", ErrColumnCountMismatch, len(left), len(right))
+	}
+
+	var columns []ResultColumn
+	for i := range left {
+		typ, ok := unifyTypes(left[i].Type, right[i].Type)
+		if !ok {
+			return nil, fmt.Errorf("%w: column %d", ErrColumnTypeMismatch, i+1)
+		}
+		columns = append(columns, ResultColumn{
+			Type: typ,
+			Name: left[i].Name,
+		})
+	}
+	return columns, nil
+}
+
+// convertRows converts result rows of the columns from to the types of the
+// columns to
+func convertRows(rows [][]Cell, from, to []ResultColumn) [][]Cell {
+	converted := make([][]Cell, 0, len(rows))
+	for _, row := range rows {
+		cells := make([]Cell, len(row))
+		for i, c := range row {
+			cells[i] = convertCell(c.(MemoryCell), from[i].Type, to[i].Type)
+		}
+		converted = append(converted, cells)
+	}
+	return converted
+}
+
+func (mb *MemoryBackend) Select(slct *SelectStatement) (*Results, error) {
+	results, err := mb.query(slct, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// columns of nothing but NULLs are reported as text
+	for i := range results.Columns {
+		if results.Columns[i].Type == nullType {
+			results.Columns[i].Type = TextType
+		}
+	}
+	return results, nil
+}
+
+// selectColumns expands the select list of a single select over the
+// relation, returning the select items and the columns they produce
+func (mb *MemoryBackend) selectColumns(slct *SelectStatement, rel *relation, outer *scope) ([]*selectItem, []ResultColumn, error) {
+	items, err := mb.expandSelectItems(rel, slct)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var columns []ResultColumn
+	for _, item := range items {
+		typ, err := mb.expressionType(&scope{relation: rel, outer: outer}, item.exp)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		columns = append(columns, ResultColumn{
+			Type: typ,
+			Name: item.name(),
+		})
+	}
+	return items, columns, nil
+}
+
+// describe returns the columns a select would produce, without running it
+func (mb *MemoryBackend) describe(slct *SelectStatement, outer *scope) ([]ResultColumn, error) {
+	if slct.setOp != nil {
+		left, err := mb.describe(slct.setOp.left, outer)
+		if err != nil {
+			return nil, err
+		}
+		right, err := mb.describe(slct.setOp.right, outer)
+		if err != nil {
+			return nil, err
+		}
+		return unifyColumns(left, right)
+	}
+
+	rel := &relation{}
+	if slct.from != nil {
+		r, err := mb.relationColumns(slct.from, outer)
+		if err != nil {
+			return nil, err
+		}
+		rel = r
+	}
+
+	_, columns, err := mb.selectColumns(slct, rel, outer)
+	return columns, err
+}
+
+// query runs a select, within the scope of the enclosing query's row when it
+// is a subquery
+func (mb *MemoryBackend) query(slct *SelectStatement, outer *scope) (*Results, error) {
+	if slct.setOp != nil {
+		return mb.selectSetOperation(slct, outer)
+	}
+
+	// without a FROM clause the select list is evaluated once, against a row
+	// with no columns
+	rel := &relation{
+		rows: [][]MemoryCell{nil},
+	}
+	if slct.from != nil {
+		r, err := mb.buildRelation(slct.from, outer)
+		if err != nil {
+			return nil, err
+		}
+		rel = r
+	}
+
+	items, columns, err := mb.selectColumns(slct, rel, outer)
+	if err != nil {
+		return nil, err
+	}
+
+	var results [][]Cell
+	keys, err := mb.resolveSortKeys(&scope{relation: rel, outer: outer}, slct.orderBy, items, columns)
+	if err != nil {
+		return nil, err
+	}
+	if slct.distinct {
+		for _, key := range keys {
+			if key.column < 0 {
+				return nil, fmt.Errorf("%w: for SELECT DISTINCT %s must appear in the select list", ErrInvalidSortKey, key.exp.generateCode())
+			}
+		}
+	}
+	var sortValues [][]MemoryCell
+
+	offset, err := mb.evaluateLimit(slct.offset, 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := mb.evaluateLimit(slct.limit, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	next := mb.filterRows(rel, slct.where, outer)
+	if isAggregating(slct, items, keys) {
+		if err := mb.checkGrouped(rel, slct, items, keys, outer); err != nil {
+			return nil, err
+		}
+		next, err = mb.groupRows(rel, next, slct, outer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// without sorting, rows come out in scan order and the scan can stop
+	// skipping and collecting rows as soon as the limit is met
+	streaming := len(keys) == 0
+	skipped := 0
+	seen := map[string]bool{}
+
+	for {
+		if streaming && limit >= 0 && len(results) >= limit {
+			break
+		}
+
+		sc, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if sc == nil {
+			break
+		}
+
+		var result []Cell
+		var values []MemoryCell
+		for _, item := range items {
+			c, _, err := mb.evaluateCell(sc, item.exp)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, c)
+			values = append(values, c)
+		}
+
+		if slct.distinct {
+			key := rowKey(result, columns)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+
+		if streaming && skipped < offset {
+			skipped++
+			continue
+		}
+
+		if len(keys) > 0 {
+			var sortValue []MemoryCell
+			for _, key := range keys {
+				if key.column >= 0 {
+					sortValue = append(sortValue, values[key.column])
+					continue
+				}
+				c, _, err := mb.evaluateCell(sc, key.exp)
+				if err != nil {
+					return nil, err
+				}
+				sortValue = append(sortValue, c)
+			}
+			sortValues = append(sortValues, sortValue)
+		}
+		results = append(results, result)
+	}
+
+	if !streaming {
+		results = sortAndLimit(results, sortValues, keys, offset, limit)
 	}
 	return &Results{
 		Columns: columns,
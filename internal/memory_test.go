@@ -0,0 +1,781 @@
+package godb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// sqlTest is a statement and its expected outcome: the rows of a SELECT,
+// each rendered as its cells joined by ", ", or the error it fails with
+type sqlTest struct {
+	sql  string
+	rows []string
+	err  error
+}
+
+// runSQL executes the statements in order against a new backend, checking
+// the outcome of each. Statements expecting neither rows nor an error only
+// have to succeed.
+func runSQL(t *testing.T, tests []sqlTest) {
+	t.Helper()
+	mb := NewMemoryBackend()
+	for _, test := range tests {
+		rows, err := execSQL(mb, test.sql)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("%s: got error %v, want %v", test.sql, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.sql, err)
+			continue
+		}
+		if test.rows != nil && strings.Join(rows, "\n") != strings.Join(test.rows, "\n") {
+			t.Errorf("%s: got rows %q, want %q", test.sql, rows, test.rows)
+		}
+	}
+}
+
+// execSQL executes the statements of source, returning the rows of those
+// that are queries
+func execSQL(mb *MemoryBackend, source string) ([]string, error) {
+	ast, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []string
+	for _, stmt := range ast.Statements {
+		switch stmt.Kind {
+		case CreateStmtKind:
+			err = mb.CreateTable(stmt.CreateStatement)
+		case AlterStmtKind:
+			err = mb.AlterTable(stmt.AlterStatement)
+		case DropStmtKind:
+			err = mb.DropTable(stmt.DropStatement)
+		case InsertStmtKind:
+			err = mb.Insert(stmt.InsertStatement)
+		case UpdateStmtKind:
+			_, err = mb.Update(stmt.UpdateStatement)
+		case DeleteStmtKind:
+			_, err = mb.Delete(stmt.DeleteStatement)
+		case SelectStmtKind:
+			var results *Results
+			results, err = mb.Select(stmt.SelectStatement)
+			if err != nil {
+				break
+			}
+			for _, result := range results.Row {
+				var cells []string
+				for i, cell := range result {
+					s, err := formatCell(cell, results.Columns[i].Type)
+					if err != nil {
+						return nil, err
+					}
+					cells = append(cells, s)
+				}
+				rows = append(rows, strings.Join(cells, ", "))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+func TestWhere(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE users (id int, name text, age int);"},
+		{sql: "INSERT INTO users VALUES (1, 'ann', 31);"},
+		{sql: "INSERT INTO users VALUES (2, 'bob', 25);"},
+		{sql: "INSERT INTO users VALUES (3, 'cid', 40);"},
+
+		{sql: "SELECT id FROM users WHERE age > 30;", rows: []string{"1", "3"}},
+		{sql: "SELECT id FROM users WHERE age >= 31 AND age <= 39;", rows: []string{"1"}},
+		{sql: "SELECT id FROM users WHERE name = 'bob' OR id = 3;", rows: []string{"2", "3"}},
+		{sql: "SELECT id FROM users WHERE name <> 'bob';", rows: []string{"1", "3"}},
+		{sql: "SELECT id FROM users WHERE NOT age < 31;", rows: []string{"1", "3"}},
+		{sql: "SELECT id FROM users WHERE 25 = age;", rows: []string{"2"}},
+
+		// AND binds tighter than OR, and NOT than AND
+		{sql: "SELECT id FROM users WHERE id = 2 OR id = 1 AND age > 35;", rows: []string{"2"}},
+		{sql: "SELECT id FROM users WHERE (id = 2 OR id = 1) AND age > 35;", rows: []string{}},
+		{sql: "SELECT id FROM users WHERE NOT id = 1 AND NOT id = 3;", rows: []string{"2"}},
+
+		// texts compare bytewise
+		{sql: "SELECT name FROM users WHERE name < 'b';", rows: []string{"ann"}},
+
+		{sql: "SELECT id FROM users WHERE age = name;", err: ErrInvalidOperands},
+		{sql: "SELECT id FROM users WHERE missing = 1;", err: ErrColumnDoesNotExist},
+		{sql: "SELECT id FROM users WHERE age;", err: ErrInvalidPredicate},
+	})
+}
+
+func TestArithmetic(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE items (name text, price int, qty int);"},
+		{sql: "INSERT INTO items VALUES ('pen', 3, 10);"},
+		{sql: "INSERT INTO items VALUES ('ink' || 's', 2 * 5 + 1, -(2 - 4));"},
+
+		{sql: "SELECT name, price * qty FROM items;", rows: []string{"pen, 30", "inks, 22"}},
+		{sql: "SELECT 1 + 2 * 3, (1 + 2) * 3, 10 - 4 - 3, -price FROM items WHERE name = 'pen';", rows: []string{"7, 9, 3, -3"}},
+		{sql: "SELECT 7 / 2, -7 / 2, 7 % 3, -7 % 3 FROM items WHERE name = 'pen';", rows: []string{"3, -3, 1, -1"}},
+		{sql: "SELECT name || ': ' || price FROM items;", rows: []string{"pen: 3", "inks: 11"}},
+		{sql: "SELECT name FROM items WHERE price * qty > 25 AND qty - 1 >= 9;", rows: []string{"pen"}},
+
+		{sql: "SELECT price / (qty - 10) FROM items;", err: ErrDivisionByZero},
+		{sql: "SELECT price % 0 FROM items;", err: ErrDivisionByZero},
+		{sql: "SELECT name + 1 FROM items;", err: ErrInvalidOperands},
+		{sql: "SELECT -name FROM items;", err: ErrInvalidOperands},
+		{sql: "INSERT INTO items VALUES ('x', 1 / 0, 1);", err: ErrDivisionByZero},
+		{sql: "INSERT INTO items VALUES ('x', price, 1);", err: ErrColumnDoesNotExist},
+	})
+}
+
+func TestIntegerOverflow(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "SELECT 9223372036854775806 + 1, -9223372036854775807 - 1, 3 * -4, -7 / 2, -7 % 2;", rows: []string{"9223372036854775807, -9223372036854775808, -12, -3, -1"}},
+		{sql: "SELECT 9223372036854775807 + 1;", err: ErrIntegerOutOfRange},
+		{sql: "SELECT -9223372036854775807 - 2;", err: ErrIntegerOutOfRange},
+		{sql: "SELECT 2 * 4611686018427387904;", err: ErrIntegerOutOfRange},
+		{sql: "SELECT -1 * (-9223372036854775807 - 1);", err: ErrIntegerOutOfRange},
+		{sql: "SELECT (-9223372036854775807 - 1) / -1;", err: ErrIntegerOutOfRange},
+		{sql: "SELECT -(-9223372036854775807 - 1);", err: ErrIntegerOutOfRange},
+		{sql: "SELECT 1 / 0;", err: ErrDivisionByZero},
+
+		{sql: "CREATE TABLE s (v int);"},
+		{sql: "INSERT INTO s VALUES (9223372036854775807), (1), (-1);"},
+		// partial sums may overflow as long as the total does not
+		{sql: "SELECT sum(v) FROM s;", rows: []string{"9223372036854775807"}},
+		{sql: "INSERT INTO s VALUES (1);"},
+		{sql: "SELECT sum(v) FROM s;", err: ErrIntegerOutOfRange},
+	})
+}
+
+func TestSelectStar(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE pets (name text, legs int);"},
+		{sql: "INSERT INTO pets VALUES ('cat', 4);"},
+		{sql: "INSERT INTO pets VALUES ('bird', 2);"},
+
+		{sql: "SELECT * FROM pets;", rows: []string{"cat, 4", "bird, 2"}},
+		{sql: "SELECT legs, *, pets.* FROM pets WHERE legs = 2;", rows: []string{"2, bird, 2, bird, 2"}},
+		{sql: "SELECT plants.* FROM pets;", err: ErrTableDoesNotExist},
+
+		// without FROM the select list is evaluated once
+		{sql: "SELECT 1 + 1, 'a' || 'b';", rows: []string{"2, ab"}},
+		{sql: "SELECT 1 WHERE 1 = 2;", rows: []string{}},
+		{sql: "SELECT *;", err: ErrInvalidSelectItem},
+		{sql: "SELECT legs;", err: ErrColumnDoesNotExist},
+	})
+}
+
+func TestColumnNames(t *testing.T) {
+	mb := NewMemoryBackend()
+	if _, err := execSQL(mb, "CREATE TABLE items (name text, price int);"); err != nil {
+		t.Fatal(err)
+	}
+
+	ast, err := parse(`SELECT name, price AS Cost, price * 2 "Double", price + 1, * FROM items;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := mb.Select(ast.Statements[0].SelectStatement)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// unquoted aliases are lower cased, computed columns are named after
+	// their expression
+	want := []string{"name", "cost", "Double", "price + 1", "name", "price"}
+	if len(results.Columns) != len(want) {
+		t.Fatalf("got %d columns, want %d", len(results.Columns), len(want))
+	}
+	for i, col := range results.Columns {
+		if col.Name != want[i] {
+			t.Errorf("column %d: got name %q, want %q", i, col.Name, want[i])
+		}
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE scores (name text, team text, points int);"},
+		{sql: "INSERT INTO scores VALUES ('ann', 'red', 7);"},
+		{sql: "INSERT INTO scores VALUES ('bob', 'blue', 9);"},
+		{sql: "INSERT INTO scores VALUES ('cid', 'red', 9);"},
+		{sql: "INSERT INTO scores VALUES ('dan', 'blue', 3);"},
+
+		{sql: "SELECT name FROM scores ORDER BY points;", rows: []string{"dan", "ann", "bob", "cid"}},
+		{sql: "SELECT name FROM scores ORDER BY points DESC, name DESC;", rows: []string{"cid", "bob", "ann", "dan"}},
+		{sql: "SELECT name, team FROM scores ORDER BY team, points ASC;", rows: []string{"dan, blue", "bob, blue", "ann, red", "cid, red"}},
+
+		// keys may name or number output columns, or be any expression over
+		// the table
+		{sql: "SELECT name, -points AS p FROM scores ORDER BY p, 1 DESC;", rows: []string{"cid, -9", "bob, -9", "ann, -7", "dan, -3"}},
+		{sql: "SELECT name FROM scores ORDER BY points % 3, name;", rows: []string{"bob", "cid", "dan", "ann"}},
+		{sql: "SELECT name FROM scores WHERE team = 'red' ORDER BY team || name DESC;", rows: []string{"cid", "ann"}},
+
+		// rows with equal keys keep their order
+		{sql: "SELECT name FROM scores ORDER BY team;", rows: []string{"bob", "dan", "ann", "cid"}},
+
+		{sql: "SELECT name FROM scores ORDER BY 2;", err: ErrInvalidSortKey},
+		{sql: "SELECT name FROM scores ORDER BY 0;", err: ErrInvalidSortKey},
+		{sql: "SELECT name FROM scores ORDER BY missing;", err: ErrColumnDoesNotExist},
+	})
+}
+
+func TestLimit(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE n (i int);"},
+		{sql: "INSERT INTO n VALUES (4);"},
+		{sql: "INSERT INTO n VALUES (1);"},
+		{sql: "INSERT INTO n VALUES (3);"},
+		{sql: "INSERT INTO n VALUES (2);"},
+
+		{sql: "SELECT i FROM n LIMIT 2;", rows: []string{"4", "1"}},
+		{sql: "SELECT i FROM n LIMIT 2 OFFSET 1;", rows: []string{"1", "3"}},
+		{sql: "SELECT i FROM n OFFSET 1 LIMIT 1 + 1;", rows: []string{"1", "3"}},
+		{sql: "SELECT i FROM n WHERE i > 1 OFFSET 2;", rows: []string{"2"}},
+		{sql: "SELECT i FROM n ORDER BY i DESC LIMIT 3 OFFSET 1;", rows: []string{"3", "2", "1"}},
+		{sql: "SELECT i FROM n LIMIT 0;", rows: []string{}},
+		{sql: "SELECT i FROM n OFFSET 10;", rows: []string{}},
+		{sql: "SELECT i FROM n ORDER BY i OFFSET 10;", rows: []string{}},
+
+		// without ORDER BY the scan stops once the limit is met: the row
+		// holding 2 would fail with a division by zero
+		{sql: "SELECT 10 / (i - 2) FROM n LIMIT 3;", rows: []string{"5", "-10", "10"}},
+		{sql: "SELECT 10 / (i - 2) FROM n LIMIT 1 OFFSET 2;", rows: []string{"10"}},
+		{sql: "SELECT 10 / (i - 2) FROM n ORDER BY i LIMIT 1;", err: ErrDivisionByZero},
+
+		{sql: "SELECT i FROM n LIMIT -1;", err: ErrInvalidLimit},
+		{sql: "SELECT i FROM n LIMIT 'a';", err: ErrInvalidLimit},
+		{sql: "SELECT i FROM n LIMIT i;", err: ErrColumnDoesNotExist},
+	})
+}
+
+func TestGroupBy(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE sales (region text, item text, qty int);"},
+		{sql: "INSERT INTO sales VALUES ('north', 'pen', 5);"},
+		{sql: "INSERT INTO sales VALUES ('south', 'pen', 2);"},
+		{sql: "INSERT INTO sales VALUES ('north', 'ink', 4);"},
+		{sql: "INSERT INTO sales VALUES ('north', 'pen', 6);"},
+		{sql: "INSERT INTO sales VALUES ('south', 'cap', 9);"},
+
+		// without GROUP BY every row forms a single group
+		{sql: "SELECT count(*), sum(qty), min(qty), max(item), avg(qty) FROM sales;", rows: []string{"5, 26, 2, pen, 5.2"}},
+		{sql: "SELECT count(*) FROM sales WHERE qty > 100;", rows: []string{"0"}},
+		{sql: "SELECT count(DISTINCT item), count(item), sum(DISTINCT qty % 3) FROM sales;", rows: []string{"3, 5, 3"}},
+
+		// groups come out in the order they are first seen
+		{sql: "SELECT region, count(*), sum(qty) FROM sales GROUP BY region;", rows: []string{"north, 3, 15", "south, 2, 11"}},
+		{sql: "SELECT region, item, sum(qty) FROM sales GROUP BY region, item ORDER BY 3 DESC;", rows: []string{"north, pen, 11", "south, cap, 9", "north, ink, 4", "south, pen, 2"}},
+		{sql: "SELECT qty % 2, count(*) FROM sales GROUP BY qty % 2 ORDER BY qty % 2;", rows: []string{"0, 3", "1, 2"}},
+		{sql: "SELECT region || '!', max(qty) - min(qty) FROM sales GROUP BY region;", rows: []string{"north!, 2", "south!, 7"}},
+
+		{sql: "SELECT item FROM sales GROUP BY item HAVING sum(qty) > 8 ORDER BY item;", rows: []string{"cap", "pen"}},
+		{sql: "SELECT item FROM sales GROUP BY item HAVING count(*) = 1 AND item <> 'cap';", rows: []string{"ink"}},
+		{sql: "SELECT region FROM sales GROUP BY region ORDER BY count(*);", rows: []string{"south", "north"}},
+		{sql: "SELECT count(*) FROM sales HAVING count(*) > 10;", rows: []string{}},
+
+		{sql: "SELECT region, item FROM sales GROUP BY region;", err: ErrColumnNotGrouped},
+		{sql: "SELECT region FROM sales GROUP BY region HAVING qty > 1;", err: ErrColumnNotGrouped},
+		{sql: "SELECT region FROM sales GROUP BY region ORDER BY item;", err: ErrColumnNotGrouped},
+		{sql: "SELECT item, count(*) FROM sales;", err: ErrColumnNotGrouped},
+		{sql: "SELECT item FROM sales WHERE count(*) > 1;", err: ErrInvalidAggregate},
+		{sql: "SELECT sum(count(*)) FROM sales;", err: ErrInvalidAggregate},
+		{sql: "SELECT count(*) FROM sales GROUP BY count(*);", err: ErrInvalidAggregate},
+		{sql: "SELECT sum(item) FROM sales;", err: ErrInvalidArguments},
+		{sql: "SELECT max(*) FROM sales;", err: ErrInvalidArguments},
+		{sql: "SELECT count(qty, item) FROM sales;", err: ErrInvalidArguments},
+		{sql: "SELECT median(qty) FROM sales;", err: ErrFunctionDoesNotExist},
+	})
+}
+
+func TestDistinct(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE visits (page text, user text);"},
+		{sql: "INSERT INTO visits VALUES ('home', 'ann');"},
+		{sql: "INSERT INTO visits VALUES ('about', 'bob');"},
+		{sql: "INSERT INTO visits VALUES ('home', 'bob');"},
+		{sql: "INSERT INTO visits VALUES ('home', 'ann');"},
+
+		{sql: "SELECT DISTINCT page FROM visits;", rows: []string{"home", "about"}},
+		{sql: "SELECT DISTINCT page, user FROM visits ORDER BY user, page;", rows: []string{"home, ann", "about, bob", "home, bob"}},
+		{sql: "SELECT ALL page FROM visits WHERE user = 'ann';", rows: []string{"home", "home"}},
+		// duplicates are removed before the offset and limit apply
+		{sql: "SELECT DISTINCT user FROM visits LIMIT 1 OFFSET 1;", rows: []string{"bob"}},
+		{sql: "SELECT DISTINCT page FROM visits ORDER BY page LIMIT 5;", rows: []string{"about", "home"}},
+		// texts that would concatenate alike are still distinct
+		{sql: "SELECT DISTINCT page || 'x', user FROM visits WHERE page = 'home';", rows: []string{"homex, ann", "homex, bob"}},
+	})
+}
+
+func TestSetOperations(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE a (n int);"},
+		{sql: "CREATE TABLE b (n int, s text);"},
+		{sql: "INSERT INTO a VALUES (1);"},
+		{sql: "INSERT INTO a VALUES (2);"},
+		{sql: "INSERT INTO a VALUES (2);"},
+		{sql: "INSERT INTO a VALUES (3);"},
+		{sql: "INSERT INTO b VALUES (2, 'two');"},
+		{sql: "INSERT INTO b VALUES (4, 'four');"},
+
+		{sql: "SELECT n FROM a UNION SELECT n FROM b;", rows: []string{"1", "2", "3", "4"}},
+		{sql: "SELECT n FROM a UNION ALL SELECT n FROM b;", rows: []string{"1", "2", "2", "3", "2", "4"}},
+		{sql: "SELECT n FROM a INTERSECT SELECT n FROM b;", rows: []string{"2"}},
+		{sql: "SELECT n FROM a INTERSECT ALL SELECT n FROM a WHERE n = 2;", rows: []string{"2", "2"}},
+		{sql: "SELECT n FROM a EXCEPT SELECT n FROM b;", rows: []string{"1", "3"}},
+		{sql: "SELECT n FROM a EXCEPT ALL SELECT n FROM b;", rows: []string{"1", "2", "3"}},
+
+		// INTERSECT binds tighter, and the ORDER BY and LIMIT apply to the
+		// whole set operation
+		{sql: "SELECT n FROM b UNION SELECT n FROM a INTERSECT SELECT 3;", rows: []string{"2", "4", "3"}},
+		{sql: "SELECT n AS m FROM a UNION SELECT n FROM b ORDER BY m DESC LIMIT 2;", rows: []string{"4", "3"}},
+		{sql: "SELECT s FROM b UNION SELECT 'one' ORDER BY 1;", rows: []string{"four", "one", "two"}},
+
+		{sql: "SELECT n FROM a UNION SELECT n, s FROM b;", err: ErrColumnCountMismatch},
+		{sql: "SELECT n FROM a UNION SELECT s FROM b;", err: ErrColumnTypeMismatch},
+	})
+}
+
+func TestSubqueries(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE depts (id int, name text);"},
+		{sql: "CREATE TABLE emps (name text, dept int, pay int);"},
+		{sql: "INSERT INTO depts VALUES (1, 'ops');"},
+		{sql: "INSERT INTO depts VALUES (2, 'dev');"},
+		{sql: "INSERT INTO depts VALUES (3, 'art');"},
+		{sql: "INSERT INTO emps VALUES ('ann', 1, 50);"},
+		{sql: "INSERT INTO emps VALUES ('bob', 2, 70);"},
+		{sql: "INSERT INTO emps VALUES ('cid', 2, 90);"},
+
+		// scalar subqueries, correlated or not
+		{sql: "SELECT name FROM emps WHERE pay >= (SELECT avg(pay) FROM emps);", rows: []string{"bob", "cid"}},
+		{sql: "SELECT name, (SELECT count(*) FROM emps WHERE dept = depts.id) FROM depts;", rows: []string{"ops, 1", "dev, 2", "art, 0"}},
+		{sql: "SELECT e.name FROM emps e WHERE pay = (SELECT max(pay) FROM emps WHERE dept = e.dept);", rows: []string{"ann", "cid"}},
+		{sql: "SELECT (SELECT name FROM depts WHERE id = 9);", rows: []string{"NULL"}},
+
+		{sql: "SELECT name FROM depts WHERE id IN (SELECT dept FROM emps);", rows: []string{"ops", "dev"}},
+		{sql: "SELECT name FROM depts WHERE id NOT IN (SELECT dept FROM emps);", rows: []string{"art"}},
+		{sql: "SELECT name FROM depts WHERE name IN ('art', 'ops') AND id + 1 NOT IN (1, 2);", rows: []string{"art"}},
+		{sql: "SELECT name FROM depts d WHERE EXISTS (SELECT 1 FROM emps WHERE dept = d.id AND pay > 60);", rows: []string{"dev"}},
+		{sql: "SELECT name FROM depts d WHERE NOT EXISTS (SELECT * FROM emps WHERE dept = d.id);", rows: []string{"art"}},
+
+		// derived tables are read like tables
+		{sql: "SELECT t.dept, t.total FROM (SELECT dept, sum(pay) AS total FROM emps GROUP BY dept) AS t WHERE t.total > 60;", rows: []string{"2, 160"}},
+		{sql: "SELECT d.name, t.n FROM depts d JOIN (SELECT dept, count(*) n FROM emps GROUP BY dept) t ON d.id = t.dept;", rows: []string{"ops, 1", "dev, 2"}},
+		{sql: "SELECT count(*) FROM (SELECT DISTINCT dept FROM emps) AS x;", rows: []string{"2"}},
+
+		{sql: "SELECT (SELECT name FROM emps);", err: ErrSubqueryRows},
+		{sql: "SELECT (SELECT name, pay FROM emps WHERE pay = 50);", err: ErrSubqueryColumns},
+		{sql: "SELECT name FROM depts WHERE id IN (SELECT dept, pay FROM emps);", err: ErrSubqueryColumns},
+		{sql: "SELECT name FROM depts WHERE id IN (SELECT name FROM emps);", err: ErrInvalidOperands},
+		{sql: "SELECT name FROM depts WHERE id IN ('a');", err: ErrInvalidOperands},
+		{sql: "SELECT name FROM depts WHERE (SELECT missing FROM emps) = 1;", err: ErrColumnDoesNotExist},
+	})
+}
+
+func TestGroupedSubqueries(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE emps (name text, dept int, pay int);"},
+		{sql: "CREATE TABLE depts (id int, name text);"},
+		{sql: "INSERT INTO emps VALUES ('ann', 1, 50), ('bob', 2, 70), ('cid', 2, 90);"},
+		{sql: "INSERT INTO depts VALUES (1, 'ops'), (2, 'dev');"},
+
+		// subqueries are evaluated once per group, reading grouped columns
+		// of the outer query
+		{sql: "SELECT dept, (SELECT name FROM depts WHERE id = dept) FROM emps GROUP BY dept ORDER BY dept;", rows: []string{"1, ops", "2, dev"}},
+		{sql: "SELECT e.dept, (SELECT max(e.dept) + count(*) FROM depts) FROM emps e GROUP BY dept ORDER BY dept;", rows: []string{"1, 3", "2, 4"}},
+		{sql: "SELECT dept FROM emps GROUP BY dept HAVING EXISTS (SELECT 1 FROM depts d WHERE d.id = dept AND d.name = 'dev');", rows: []string{"2"}},
+		{sql: "SELECT count(*) FROM emps WHERE pay > (SELECT min(pay) FROM emps) GROUP BY dept;", rows: []string{"2"}},
+		{sql: "SELECT dept, sum((SELECT count(*) FROM depts WHERE id = pay)) FROM emps GROUP BY dept ORDER BY dept;", rows: []string{"1, 0", "2, 0"}},
+
+		// but not ungrouped ones, even within aggregates of the subquery
+		{sql: "SELECT dept, (SELECT pay) FROM emps GROUP BY dept;", err: ErrColumnNotGrouped},
+		{sql: "SELECT dept, (SELECT max(pay) FROM depts) FROM emps GROUP BY dept;", err: ErrColumnNotGrouped},
+		{sql: "SELECT dept FROM emps GROUP BY dept HAVING EXISTS (SELECT 1 FROM depts WHERE name = emps.name);", err: ErrColumnNotGrouped},
+		{sql: "SELECT dept FROM emps GROUP BY dept HAVING 1 IN (SELECT id FROM depts UNION SELECT pay);", err: ErrColumnNotGrouped},
+		{sql: "SELECT dept, (SELECT n FROM (SELECT pay AS n) AS t) FROM emps GROUP BY dept;", err: ErrColumnNotGrouped},
+		{sql: "SELECT dept, (SELECT count(*) FROM depts a JOIN depts b ON a.id = pay) FROM emps GROUP BY dept;", err: ErrColumnNotGrouped},
+		{sql: "SELECT dept, (SELECT pay + 1) FROM emps GROUP BY dept, pay + 1;", err: ErrColumnNotGrouped},
+		{sql: "SELECT (SELECT name) FROM emps GROUP BY dept ORDER BY (SELECT pay);", err: ErrColumnNotGrouped},
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	mb := NewMemoryBackend()
+	if _, err := execSQL(mb, "CREATE TABLE stock (item text, qty int, price int);"+
+		"INSERT INTO stock VALUES ('pen', 5, 2);"+
+		"INSERT INTO stock VALUES ('ink', 0, 7);"+
+		"INSERT INTO stock VALUES ('pad', 3, 4);"); err != nil {
+		t.Fatal(err)
+	}
+
+	updates := []struct {
+		sql     string
+		updated int
+	}{
+		{"UPDATE stock SET qty = qty + 10 WHERE qty < 4;", 2},
+		{"UPDATE stock SET price = price * 2;", 3},
+		{"UPDATE stock SET qty = 0 WHERE item = 'cap';", 0},
+		// every value is computed from the row as it was before the update
+		{"UPDATE stock SET qty = price, price = qty WHERE item = 'pad';", 1},
+		{"UPDATE stock SET item = (SELECT max(item) FROM stock) || '!' WHERE qty >= 10;", 1},
+	}
+	for _, test := range updates {
+		ast, err := parse(test.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", test.sql, err)
+		}
+		updated, err := mb.Update(ast.Statements[0].UpdateStatement)
+		if err != nil {
+			t.Errorf("%s: %v", test.sql, err)
+		} else if updated != test.updated {
+			t.Errorf("%s: updated %d rows, want %d", test.sql, updated, test.updated)
+		}
+	}
+
+	rows, err := execSQL(mb, "SELECT item, qty, price FROM stock;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"pen, 5, 4", "pen!, 10, 14", "pad, 8, 13"}
+	if strings.Join(rows, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got rows %q, want %q", rows, want)
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE stock (item text, qty int);"},
+		{sql: "INSERT INTO stock VALUES ('pen', 5);"},
+		{sql: "INSERT INTO stock VALUES ('ink', 0);"},
+
+		// no row is changed when any of them fails
+		{sql: "UPDATE stock SET qty = 10 / qty;", err: ErrDivisionByZero},
+		{sql: "UPDATE stock SET qty = 'many';", err: ErrValueTypeMismatch},
+		{sql: "UPDATE stock SET qty = 1, qty = 2;", err: ErrDuplicateColumnName},
+		{sql: "UPDATE stock SET missing = 1;", err: ErrColumnDoesNotExist},
+		{sql: "UPDATE stock SET qty = 1 WHERE missing = 1;", err: ErrColumnDoesNotExist},
+		{sql: "UPDATE stock SET qty = count(*);", err: ErrInvalidAggregate},
+		{sql: "UPDATE missing SET qty = 1;", err: ErrTableDoesNotExist},
+		{sql: "SELECT item, qty FROM stock;", rows: []string{"pen, 5", "ink, 0"}},
+	})
+}
+
+func TestDelete(t *testing.T) {
+	mb := NewMemoryBackend()
+	if _, err := execSQL(mb, "CREATE TABLE log (id int, level text);"+
+		"INSERT INTO log VALUES (1, 'info');"+
+		"INSERT INTO log VALUES (2, 'error');"+
+		"INSERT INTO log VALUES (3, 'info');"+
+		"INSERT INTO log VALUES (4, 'debug');"); err != nil {
+		t.Fatal(err)
+	}
+
+	deletes := []struct {
+		sql     string
+		deleted int
+		ids     []string
+	}{
+		{"DELETE FROM log WHERE level = 'debug';", 1, []string{"1", "2", "3"}},
+		{"DELETE FROM log WHERE id > 10;", 0, []string{"1", "2", "3"}},
+		{"DELETE FROM log WHERE id IN (SELECT id FROM log WHERE level = 'info');", 2, []string{"2"}},
+		{"DELETE FROM log;", 1, []string{}},
+		{"TRUNCATE log;", 0, []string{}},
+	}
+	for _, test := range deletes {
+		ast, err := parse(test.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", test.sql, err)
+		}
+		deleted, err := mb.Delete(ast.Statements[0].DeleteStatement)
+		if err != nil {
+			t.Errorf("%s: %v", test.sql, err)
+		} else if deleted != test.deleted {
+			t.Errorf("%s: deleted %d rows, want %d", test.sql, deleted, test.deleted)
+		}
+
+		ids, err := execSQL(mb, "SELECT id FROM log;")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(ids, ",") != strings.Join(test.ids, ",") {
+			t.Errorf("%s: left rows %q, want %q", test.sql, ids, test.ids)
+		}
+	}
+}
+
+func TestDeleteErrors(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE log (id int, level text);"},
+		{sql: "INSERT INTO log VALUES (1, 'info');"},
+		{sql: "INSERT INTO log VALUES (0, 'error');"},
+
+		// no row is removed when the predicate fails on any of them
+		{sql: "DELETE FROM log WHERE 1 / id = 1;", err: ErrDivisionByZero},
+		{sql: "DELETE FROM log WHERE level = 1;", err: ErrInvalidOperands},
+		{sql: "DELETE FROM missing;", err: ErrTableDoesNotExist},
+		{sql: "TRUNCATE TABLE missing;", err: ErrTableDoesNotExist},
+		{sql: "SELECT count(*) FROM log;", rows: []string{"2"}},
+		{sql: "TRUNCATE TABLE log;"},
+		{sql: "SELECT count(*) FROM log;", rows: []string{"0"}},
+	})
+}
+
+func TestCreateAndDropTable(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE a (x int);"},
+		{sql: "INSERT INTO a VALUES (1);"},
+		{sql: "CREATE TABLE a (y text);", err: ErrTableAlreadyExists},
+		// an existing table is kept as it is
+		{sql: "CREATE TABLE IF NOT EXISTS a (y text);"},
+		{sql: "SELECT * FROM a;", rows: []string{"1"}},
+
+		// invalid definitions create no table
+		{sql: "CREATE TABLE b (x int, x text);", err: ErrDuplicateColumnName},
+		{sql: "SELECT * FROM b;", err: ErrTableDoesNotExist},
+		{sql: "CREATE TABLE b (x int);"},
+
+		// no table is dropped if any of them does not exist
+		{sql: "DROP TABLE a, c;", err: ErrTableDoesNotExist},
+		{sql: "SELECT * FROM a;", rows: []string{"1"}},
+		{sql: "DROP TABLE IF EXISTS a, c;"},
+		{sql: "SELECT * FROM a;", err: ErrTableDoesNotExist},
+		{sql: "DROP TABLE b;"},
+		{sql: "DROP TABLE b;", err: ErrTableDoesNotExist},
+
+		// a dropped table can be created anew
+		{sql: "CREATE TABLE a (z text);"},
+		{sql: "SELECT * FROM a;", rows: []string{}},
+	})
+}
+
+func TestAlterTable(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE people (id int, name text);"},
+		{sql: "INSERT INTO people VALUES (1, 'ann');"},
+		{sql: "INSERT INTO people VALUES (2, 'bob');"},
+
+		// existing rows take the default of an added column, NULL without one
+		{sql: "ALTER TABLE people ADD COLUMN age int DEFAULT 20 + 1;"},
+		{sql: "ALTER TABLE people ADD nick text;"},
+		{sql: "SELECT * FROM people;", rows: []string{"1, ann, 21, NULL", "2, bob, 21, NULL"}},
+		{sql: "INSERT INTO people VALUES (3, 'cid', 40, 'c');"},
+
+		{sql: "ALTER TABLE people DROP COLUMN name;"},
+		{sql: "ALTER TABLE people RENAME COLUMN nick TO alias;"},
+		{sql: "ALTER TABLE people RENAME age TO years;"},
+		{sql: "SELECT * FROM people WHERE years > 30;", rows: []string{"3, 40, c"}},
+		{sql: "ALTER TABLE people RENAME TO persons;"},
+		{sql: "SELECT * FROM people;", err: ErrTableDoesNotExist},
+		{sql: "SELECT id, alias FROM persons ORDER BY id DESC LIMIT 1;", rows: []string{"3, c"}},
+
+		{sql: "ALTER TABLE persons ADD id int;", err: ErrDuplicateColumnName},
+		{sql: "ALTER TABLE persons ADD x int DEFAULT 'one';", err: ErrValueTypeMismatch},
+		{sql: "ALTER TABLE persons ADD x int DEFAULT id;", err: ErrColumnDoesNotExist},
+		{sql: "ALTER TABLE persons ADD x int DEFAULT 1 / 0;", err: ErrDivisionByZero},
+		{sql: "ALTER TABLE persons DROP name;", err: ErrColumnDoesNotExist},
+		{sql: "ALTER TABLE persons RENAME COLUMN name TO title;", err: ErrColumnDoesNotExist},
+		{sql: "ALTER TABLE persons RENAME id TO years;", err: ErrDuplicateColumnName},
+		{sql: "CREATE TABLE other (x int);"},
+		{sql: "ALTER TABLE persons RENAME TO other;", err: ErrTableAlreadyExists},
+		{sql: "ALTER TABLE missing DROP x;", err: ErrTableDoesNotExist},
+		// failed changes leave the table as it was
+		{sql: "SELECT * FROM persons WHERE id = 1;", rows: []string{"1, 21, NULL"}},
+	})
+}
+
+func TestAlterTableKeepsResults(t *testing.T) {
+	mb := NewMemoryBackend()
+	if _, err := execSQL(mb, "CREATE TABLE t (a int, b int); INSERT INTO t VALUES (1, 2);"); err != nil {
+		t.Fatal(err)
+	}
+	ast, err := parse("SELECT * FROM t;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := mb.Select(ast.Statements[0].SelectStatement)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rows are rewritten rather than changed in place, results already read
+	// keep their shape and values
+	if _, err := execSQL(mb, "ALTER TABLE t DROP a; ALTER TABLE t ADD c int DEFAULT 3;"); err != nil {
+		t.Fatal(err)
+	}
+	row := results.Row[0]
+	if len(row) != 2 {
+		t.Fatalf("results read before the change hold %d cells", len(row))
+	}
+	a, _ := row[0].AsInt()
+	b, _ := row[1].AsInt()
+	if a != 1 || b != 2 {
+		t.Errorf("results read before the change hold %d, %d", a, b)
+	}
+}
+
+func TestInsert(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE notes (id int, body text);"},
+		{sql: "ALTER TABLE notes ADD stars int DEFAULT 3;"},
+
+		{sql: "INSERT INTO notes VALUES (1, 'a', 5), (2, 'b', 4);"},
+		// columns left out take their default, NULL without one
+		{sql: "INSERT INTO notes (body, id) VALUES ('c', 3);"},
+		{sql: "INSERT INTO notes (stars) VALUES (1), (2 * 1);"},
+		{sql: "SELECT * FROM notes;", rows: []string{"1, a, 5", "2, b, 4", "3, c, 3", "NULL, NULL, 1", "NULL, NULL, 2"}},
+
+		// no row is added if any of them fails
+		{sql: "INSERT INTO notes VALUES (6, 'f', 1), (7, 'g', 1 / 0);", err: ErrDivisionByZero},
+		{sql: "INSERT INTO notes VALUES (6, 'f', 1), (7, 'g');", err: ErrMissingValues},
+		{sql: "INSERT INTO notes (id) VALUES (6), (7, 'g');", err: ErrTooManyValues},
+		{sql: "INSERT INTO notes (id, id) VALUES (6, 6);", err: ErrDuplicateColumnName},
+		{sql: "INSERT INTO notes (title) VALUES ('f');", err: ErrColumnDoesNotExist},
+		{sql: "INSERT INTO missing VALUES (1);", err: ErrTableDoesNotExist},
+		{sql: "SELECT count(*) FROM notes;", rows: []string{"5"}},
+	})
+}
+
+func TestInsertSelect(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE src (id int, name text);"},
+		{sql: "INSERT INTO src VALUES (1, 'a'), (2, 'b'), (3, 'c');"},
+		{sql: "CREATE TABLE dst (name text, id int);"},
+		{sql: "ALTER TABLE dst ADD copied int DEFAULT 1;"},
+
+		{sql: "INSERT INTO dst (id, name) SELECT id, name FROM src WHERE id > 1;"},
+		{sql: "INSERT INTO dst SELECT name || name, id * 10, 0 FROM src ORDER BY id DESC LIMIT 1;"},
+		{sql: "SELECT * FROM dst;", rows: []string{"b, 2, 1", "c, 3, 1", "cc, 30, 0"}},
+
+		// the query is read in full before any row is added, so a table can
+		// be copied into itself
+		{sql: "INSERT INTO src SELECT id + 3, name FROM src;"},
+		{sql: "SELECT count(*), max(id) FROM src;", rows: []string{"6, 6"}},
+		{sql: "INSERT INTO src (id) SELECT 7 UNION SELECT 8;"},
+		{sql: "SELECT id, name FROM src WHERE id > 6;", rows: []string{"7, NULL", "8, NULL"}},
+
+		{sql: "INSERT INTO dst (name, id) SELECT id, name FROM src;", err: ErrValueTypeMismatch},
+		{sql: "INSERT INTO dst (name) SELECT name, id FROM src;", err: ErrTooManyValues},
+		{sql: "INSERT INTO dst SELECT name FROM src;", err: ErrMissingValues},
+		{sql: "INSERT INTO dst SELECT * FROM missing;", err: ErrTableDoesNotExist},
+		{sql: "SELECT count(*) FROM dst;", rows: []string{"3"}},
+	})
+}
+
+func TestNull(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE t (id int, n int, s text);"},
+		{sql: "INSERT INTO t VALUES (1, 10, 'a'), (2, NULL, 'b'), (3, 30, NULL), (4, NULL, NULL);"},
+
+		{sql: "SELECT id FROM t WHERE n IS NULL;", rows: []string{"2", "4"}},
+		{sql: "SELECT id FROM t WHERE s IS NOT NULL AND n IS NOT NULL;", rows: []string{"1"}},
+		{sql: "SELECT id, n + 1, s || 'x', -n FROM t;", rows: []string{"1, 11, ax, -10", "2, NULL, bx, NULL", "3, 31, NULL, -30", "4, NULL, NULL, NULL"}},
+		{sql: "SELECT NULL, 1 + NULL, NULL || 'a';", rows: []string{"NULL, NULL, NULL"}},
+		{sql: "SELECT 1 WHERE NULL IS NULL;", rows: []string{"1"}},
+		{sql: "SELECT 1 WHERE NULL;", rows: []string{}},
+		{sql: "SELECT 1 WHERE NOT NULL;", rows: []string{}},
+
+		// comparisons with NULL are unknown, and WHERE keeps only true rows
+		{sql: "SELECT id FROM t WHERE n = NULL OR n <> NULL;", rows: []string{}},
+		{sql: "SELECT id FROM t WHERE NOT n > 15;", rows: []string{"1"}},
+		{sql: "SELECT id FROM t WHERE n > 15 OR id = 2;", rows: []string{"2", "3"}},
+		{sql: "SELECT id FROM t WHERE NOT (n > 15 AND id = 4);", rows: []string{"1", "2", "3"}},
+		{sql: "SELECT id FROM t WHERE NOT (n > 15 OR id = 2);", rows: []string{"1"}},
+		{sql: "SELECT id FROM t WHERE (n IS NULL) = 1;", err: ErrInvalidOperands},
+		{sql: "SELECT id FROM t WHERE id NOT IN (2, NULL);", rows: []string{}},
+		{sql: "SELECT id FROM t WHERE id IN (2, NULL);", rows: []string{"2"}},
+		{sql: "SELECT id FROM t WHERE n NOT IN (SELECT n FROM t WHERE id = 1);", rows: []string{"3"}},
+
+		// aggregates skip NULLs, and are NULL over no values but for counts
+		{sql: "SELECT count(*), count(n), count(DISTINCT s), sum(n), min(s), max(n) FROM t;", rows: []string{"4, 2, 2, 40, a, 30"}},
+		{sql: "SELECT sum(n), avg(n), max(s), count(n) FROM t WHERE n IS NULL AND s IS NULL;", rows: []string{"NULL, NULL, NULL, 0"}},
+
+		// NULLs group, and are distinct, alike
+		{sql: "SELECT n, count(*) FROM t GROUP BY n;", rows: []string{"10, 1", "NULL, 2", "30, 1"}},
+		{sql: "SELECT DISTINCT s FROM t;", rows: []string{"a", "b", "NULL"}},
+		{sql: "SELECT n FROM t UNION SELECT NULL;", rows: []string{"10", "NULL", "30"}},
+
+		// NULLs sort last ascending and first descending unless told otherwise
+		{sql: "SELECT id FROM t ORDER BY n, id;", rows: []string{"1", "3", "2", "4"}},
+		{sql: "SELECT id FROM t ORDER BY n DESC, id;", rows: []string{"2", "4", "3", "1"}},
+		{sql: "SELECT id FROM t ORDER BY n NULLS FIRST, id DESC;", rows: []string{"4", "2", "1", "3"}},
+		{sql: "SELECT id FROM t ORDER BY n DESC NULLS LAST, id;", rows: []string{"3", "1", "2", "4"}},
+
+		// NULLs never match in a join
+		{sql: "SELECT a.id, b.id FROM t a JOIN t b ON a.n = b.n;", rows: []string{"1, 1", "3, 3"}},
+		{sql: "SELECT a.id, b.id FROM t a JOIN t b USING (s) WHERE a.id < 3;", rows: []string{"1, 1", "2, 2"}},
+
+		{sql: "UPDATE t SET n = NULL WHERE id = 1;"},
+		{sql: "SELECT count(n) FROM t;", rows: []string{"1"}},
+	})
+}
+
+func TestColumnTypes(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE t (id int DEFAULT '7', name text);"},
+		{sql: "CREATE TABLE bad (id int DEFAULT 'seven');", err: ErrValueTypeMismatch},
+		{sql: "CREATE TABLE bad (name text DEFAULT 1);", err: ErrValueTypeMismatch},
+
+		// string literals are read as values of the column type
+		{sql: "INSERT INTO t VALUES ('1', 'a'), (' 2 ', '3');"},
+		{sql: "INSERT INTO t (name) VALUES ('b');"},
+		{sql: "INSERT INTO t VALUES ('x', 'c');", err: ErrValueTypeMismatch},
+		{sql: "INSERT INTO t VALUES (1, 2);", err: ErrValueTypeMismatch},
+		{sql: "INSERT INTO t VALUES ('1' || '0', 'd');", err: ErrValueTypeMismatch},
+		{sql: "INSERT INTO t (id) SELECT name FROM t;", err: ErrValueTypeMismatch},
+		{sql: "UPDATE t SET id = '4' WHERE name = 'b';"},
+		{sql: "UPDATE t SET name = id;", err: ErrValueTypeMismatch},
+		{sql: "SELECT id, name FROM t ORDER BY id;", rows: []string{"1, a", "2, 3", "4, b"}},
+	})
+}
+
+func TestIntegerColumnValues(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE t (id int, n int DEFAULT 2.0);"},
+		{sql: "CREATE TABLE f (x double precision);"},
+		{sql: "INSERT INTO f VALUES (3), (3.5), (1e19);"},
+
+		// numerics without a fraction are integers
+		{sql: "INSERT INTO t VALUES (1.0, 10.000), (-2e0, 1e3);"},
+		{sql: "INSERT INTO t (id) VALUES (3.00);"},
+		{sql: "INSERT INTO t (id) SELECT x FROM f WHERE x = 3;"},
+		{sql: "UPDATE t SET n = n / 2.5 WHERE id = 1;"},
+		{sql: "SELECT id, n FROM t ORDER BY id;", rows: []string{"-2, 1000", "1, 4", "3, 2", "3, 2"}},
+
+		{sql: "INSERT INTO t (id) VALUES (1.5);", err: ErrValueTypeMismatch},
+		{sql: "INSERT INTO t (id) SELECT x FROM f WHERE x > 3 AND x < 4;", err: ErrValueTypeMismatch},
+		{sql: "UPDATE t SET n = n / 3.0;", err: ErrValueTypeMismatch},
+		{sql: "INSERT INTO t (id) VALUES (9223372036854775808.0);", err: ErrIntegerOutOfRange},
+		{sql: "INSERT INTO t (id) VALUES (-9223372036854775808.0);"},
+		{sql: "INSERT INTO t (id) SELECT x FROM f WHERE x > 4;", err: ErrIntegerOutOfRange},
+		{sql: "CREATE TABLE bad (id int DEFAULT 1.5, x int);"},
+		{sql: "INSERT INTO bad (x) VALUES (1);", err: ErrValueTypeMismatch},
+		{sql: "SELECT count(*) FROM t;", rows: []string{"5"}},
+	})
+}
+
+func TestMemoryCell(t *testing.T) {
+	if _, err := MemoryCell(nil).AsInt(); !errors.Is(err, ErrNullCell) {
+		t.Errorf("got %v decoding NULL as an int, want %v", err, ErrNullCell)
+	}
+	if _, err := MemoryCell(nil).AsText(); !errors.Is(err, ErrNullCell) {
+		t.Errorf("got %v decoding NULL as text, want %v", err, ErrNullCell)
+	}
+	if _, err := textCell("abc").AsInt(); !errors.Is(err, ErrInvalidCell) {
+		t.Errorf("got %v decoding text as an int, want %v", err, ErrInvalidCell)
+	}
+	if i, err := intCell(-42).AsInt(); err != nil || i != -42 {
+		t.Errorf("got %d, %v decoding -42, want -42", i, err)
+	}
+	if s, err := textCell("").AsText(); err != nil || s != "" {
+		t.Errorf("got %q, %v decoding empty text", s, err)
+	}
+}
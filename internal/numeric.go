@@ -0,0 +1,366 @@
+package godb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxNumericPrecision bounds the precision of NUMERIC(p, s) columns and
+	// the scale of any numeric
+	maxNumericPrecision = 1000
+	// numericQuotientDigits is the number of significant digits quotients
+	// are computed to at least
+	numericQuotientDigits = 16
+)
+
+var (
+	bigOne = big.NewInt(1)
+	bigTen = big.NewInt(10)
+)
+
+// Decimal is an exact number, Unscaled divided by 10 to the power of Scale,
+// Scale being the number of digits after the decimal point
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int32
+}
+
+// String renders the decimal with all its digits after the decimal point,
+// e.g. 1.50
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.Unscaled).String()
+	if scale := int(d.Scale); scale > 0 {
+		if len(s) <= scale {
+			s = strings.Repeat("0", scale-len(s)+1) + s
+		}
+		s = s[:len(s)-scale] + "." + s[len(s)-scale:]
+	}
+	if d.Unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Rat returns the value of the decimal as a fraction
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.Unscaled, pow10(d.Scale))
+}
+
+// rescale returns the decimal with the given number of digits after the
+// decimal point, rounding if it has more
+func (d Decimal) rescale(scale int32) Decimal {
+	if scale < d.Scale {
+		return ratDecimal(d.Rat(), scale)
+	}
+	unscaled := new(big.Int).Mul(d.Unscaled, pow10(scale-d.Scale))
+	return Decimal{Unscaled: unscaled, Scale: scale}
+}
+
+// normalize drops the trailing zeros after the decimal point, so that equal
+// decimals are normalized alike
+func (d Decimal) normalize() Decimal {
+	unscaled, scale := new(big.Int).Set(d.Unscaled), d.Scale
+	q, m := new(big.Int), new(big.Int)
+	for scale > 0 && unscaled.Sign() != 0 {
+		q.QuoRem(unscaled, bigTen, m)
+		if m.Sign() != 0 {
+			break
+		}
+		unscaled.Set(q)
+		scale--
+	}
+	if unscaled.Sign() == 0 {
+		scale = 0
+	}
+	return Decimal{Unscaled: unscaled, Scale: scale}
+}
+
+// digits is the number of digits of the decimal, those after the decimal
+// point included
+func (d Decimal) digits() int {
+	return len(new(big.Int).Abs(d.Unscaled).String())
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// ratDecimal rounds a fraction to the given number of digits after the
+// decimal point, halves away from zero
+func ratDecimal(r *big.Rat, scale int32) Decimal {
+	num := new(big.Int).Mul(r.Num(), pow10(scale))
+	q, m := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if m.Abs(m).Lsh(m, 1).Cmp(r.Denom()) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, bigOne)
+		} else {
+			q.Add(q, bigOne)
+		}
+	}
+	return Decimal{Unscaled: q, Scale: scale}
+}
+
+// intDecimal returns an integer as a decimal without digits after the
+// decimal point
+func intDecimal(i int64) Decimal {
+	return Decimal{Unscaled: big.NewInt(i)}
+}
+
+// AsDecimal decodes the cell as a numeric, failing if it is NULL or does not
+// hold one
+func (mc MemoryCell) AsDecimal() (Decimal, error) {
+	if mc == nil {
+		return Decimal{}, ErrNullCell
+	}
+	if len(mc) < 3 || mc[2] > 1 {
+		return Decimal{}, fmt.Errorf("%w: %d bytes are not a numeric", ErrInvalidCell, len(mc))
+	}
+	return mc.decimal(), nil
+}
+
+// decimal decodes a cell known to hold a numeric
+func (mc MemoryCell) decimal() Decimal {
+	unscaled := new(big.Int).SetBytes(mc[3:])
+	if mc[2] == 1 {
+		unscaled.Neg(unscaled)
+	}
+	return Decimal{Unscaled: unscaled, Scale: int32(binary.BigEndian.Uint16(mc))}
+}
+
+// numericCell encodes a decimal as its scale, its sign and the big-endian
+// bytes of its magnitude
+func numericCell(d Decimal) MemoryCell {
+	magnitude := d.Unscaled.Bytes()
+	c := make(MemoryCell, 3+len(magnitude))
+	binary.BigEndian.PutUint16(c, uint16(d.Scale))
+	if d.Unscaled.Sign() < 0 {
+		c[2] = 1
+	}
+	copy(c[3:], magnitude)
+	return c
+}
+
+// parseDecimal reads a number with an optional sign, decimal point and
+// exponent, keeping the digits it is written with, e.g. 1.50 or 15e-1
+func parseDecimal(s string) (Decimal, error) {
+	mantissa, exponent := strings.TrimSpace(s), int64(0)
+	if i := strings.IndexAny(mantissa, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(mantissa[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%w: '%s' is not a numeric", ErrInvalidCell, s)
+		}
+		mantissa, exponent = mantissa[:i], e
+	}
+
+	negative := false
+	if strings.HasPrefix(mantissa, "-") || strings.HasPrefix(mantissa, "+") {
+		negative = mantissa[0] == '-'
+		mantissa = mantissa[1:]
+	}
+	whole, fraction := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		whole, fraction = mantissa[:i], mantissa[i+1:]
+	}
+	digits := whole + fraction
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("%w: '%s' is not a numeric", ErrInvalidCell, s)
+	}
+
+	unscaled, _ := new(big.Int).SetString(digits, 10)
+	if negative {
+		unscaled.Neg(unscaled)
+	}
+	d := Decimal{Unscaled: unscaled}
+	scale := int64(len(fraction)) - exponent
+	switch {
+	case scale < 0:
+		if -scale > maxNumericPrecision {
+			return Decimal{}, fmt.Errorf("%w: %s is out of range for numeric", ErrInvalidCell, s)
+		}
+		d.Unscaled.Mul(d.Unscaled, pow10(int32(-scale)))
+	case scale > maxNumericPrecision:
+		d.Scale = int32(scale)
+		d = ratDecimal(d.Rat(), maxNumericPrecision)
+	default:
+		d.Scale = int32(scale)
+	}
+	return d, nil
+}
+
+// numericModifier is the precision and scale a NUMERIC(p, s) column is
+// declared with: its values are rounded to s digits after the decimal point
+// and may have at most p digits in all
+type numericModifier struct {
+	precision int32
+	scale     int32
+}
+
+func (mod *numericModifier) String() string {
+	return fmt.Sprintf("numeric(%d,%d)", mod.precision, mod.scale)
+}
+
+// columnModifier returns the precision and scale of a NUMERIC(p, s) column,
+// nil for columns of other types or of NUMERIC without them
+func columnModifier(datatype token) (*numericModifier, error) {
+	if !strings.HasPrefix(datatype.value, "numeric(") {
+		return nil, nil
+	}
+	var mod numericModifier
+	if _, err := fmt.Sscanf(datatype.value, "numeric(%d,%d)", &mod.precision, &mod.scale); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatatype, datatype.value)
+	}
+	if mod.precision < 1 || mod.precision > maxNumericPrecision {
+		return nil, fmt.Errorf("%w: NUMERIC precision %d must be between 1 and %d", ErrInvalidDatatype, mod.precision, maxNumericPrecision)
+	}
+	if mod.scale < 0 || mod.scale > mod.precision {
+		return nil, fmt.Errorf("%w: NUMERIC scale %d must be between 0 and precision %d", ErrInvalidDatatype, mod.scale, mod.precision)
+	}
+	return &mod, nil
+}
+
+// mayBeInteger reports whether values of the type can be integers, those
+// without a fraction
+func mayBeInteger(typ ColumnType) bool {
+	return typ == NumericType || typ == FloatType
+}
+
+// integerCell converts a numeric or float without a fraction to an integer,
+// returning nil if it has one
+func integerCell(c MemoryCell, typ ColumnType) (MemoryCell, error) {
+	var r *big.Rat
+	switch typ {
+	case NumericType:
+		r = c.decimal().Rat()
+	case FloatType:
+		// infinities and NaN are out of the range of any int
+		if r = new(big.Rat).SetFloat64(c.float()); r == nil {
+			return nil, ErrIntegerOutOfRange
+		}
+	default:
+		return nil, nil
+	}
+
+	if !r.IsInt() {
+		return nil, nil
+	}
+	if !r.Num().IsInt64() {
+		return nil, ErrIntegerOutOfRange
+	}
+	return intCell(r.Num().Int64()), nil
+}
+
+// fitNumeric rounds a value of the column at i of the table name to the
+// scale of the column, failing if it then has more digits than its
+// precision allows
+func fitNumeric(name string, t *table, i int, c MemoryCell) (MemoryCell, error) {
+	mod := t.modifiers[i]
+	if mod == nil || c == nil {
+		return c, nil
+	}
+	d := c.decimal().rescale(mod.scale)
+	if d.digits() > int(mod.precision) {
+		return nil, fmt.Errorf("%w: %s for %s.%s of type %s", ErrNumericOverflow, c.decimal(), name, t.columns[i], mod)
+	}
+	return numericCell(d), nil
+}
+
+// evaluateNumericArithmetic applies an arithmetic operator to decimals,
+// exactly but for quotients, which are rounded
+func evaluateNumericArithmetic(op token, a, b Decimal) (MemoryCell, ColumnType, error) {
+	scale := a.Scale
+	if b.Scale > scale {
+		scale = b.Scale
+	}
+
+	switch symbol(op.value) {
+	case PLUS:
+		return numericCell(addDecimals(a, b)), NumericType, nil
+	case MINUS:
+		difference := new(big.Int).Sub(a.rescale(scale).Unscaled, b.rescale(scale).Unscaled)
+		return numericCell(Decimal{Unscaled: difference, Scale: scale}), NumericType, nil
+	case ASTERISK:
+		product := Decimal{Unscaled: new(big.Int).Mul(a.Unscaled, b.Unscaled), Scale: a.Scale + b.Scale}
+		if product.Scale > maxNumericPrecision {
+			product = product.rescale(maxNumericPrecision)
+		}
+		return numericCell(product), NumericType, nil
+	case SLASH:
+		if b.Unscaled.Sign() == 0 {
+			return nil, 0, ErrDivisionByZero
+		}
+		return numericCell(numericQuotient(a.Rat(), b.Rat(), scale)), NumericType, nil
+	case PERCENT:
+		if b.Unscaled.Sign() == 0 {
+			return nil, 0, ErrDivisionByZero
+		}
+		// the remainder has the sign of the dividend
+		remainder := new(big.Int).Rem(a.rescale(scale).Unscaled, b.rescale(scale).Unscaled)
+		return numericCell(Decimal{Unscaled: remainder, Scale: scale}), NumericType, nil
+	}
+	return nil, 0, ErrInvalidOperands
+}
+
+// addDecimals adds decimals, the sum having the larger of their scales
+func addDecimals(a, b Decimal) Decimal {
+	scale := a.Scale
+	if b.Scale > scale {
+		scale = b.Scale
+	}
+	sum := new(big.Int).Add(a.rescale(scale).Unscaled, b.rescale(scale).Unscaled)
+	return Decimal{Unscaled: sum, Scale: scale}
+}
+
+// numericAggregate computes the exact sum or average of numerics
+func numericAggregate(name string, values []MemoryCell) MemoryCell {
+	sum := intDecimal(0)
+	for _, c := range values {
+		sum = addDecimals(sum, c.decimal())
+	}
+	if name == "avg" {
+		return numericCell(numericQuotient(sum.Rat(), new(big.Rat).SetInt64(int64(len(values))), sum.Scale))
+	}
+	return numericCell(sum)
+}
+
+// numericQuotient divides a by b, rounding to at least numericQuotientDigits
+// significant digits and no fewer than minScale digits after the decimal
+// point
+func numericQuotient(a, b *big.Rat, minScale int32) Decimal {
+	q := new(big.Rat).Quo(a, b)
+	scale := numericQuotientDigits - decimalWeight(q)
+	if scale < minScale {
+		scale = minScale
+	}
+	if scale < 0 {
+		scale = 0
+	}
+	if scale > maxNumericPrecision {
+		scale = maxNumericPrecision
+	}
+	return ratDecimal(q, scale)
+}
+
+// decimalWeight is the number of digits before the decimal point of a
+// fraction or, if it is less than 1, minus the number of zeros right after
+// the point
+func decimalWeight(r *big.Rat) int32 {
+	abs := new(big.Rat).Abs(r)
+	if abs.Sign() == 0 {
+		return 0
+	}
+	if whole := new(big.Int).Quo(abs.Num(), abs.Denom()); whole.Sign() > 0 {
+		return int32(len(whole.String()))
+	}
+
+	weight := int32(1)
+	one := new(big.Rat).SetInt(bigOne)
+	ten := new(big.Rat).SetInt(bigTen)
+	for ; abs.Cmp(one) < 0 && weight > -maxNumericPrecision; abs.Mul(abs, ten) {
+		weight--
+	}
+	return weight
+}
@@ -0,0 +1,128 @@
+package godb
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"0", "0"},
+		{"1.50", "1.50"},
+		{"-0.001", "-0.001"},
+		{"+.5", "0.5"},
+		{"5.", "5"},
+		{"15e-1", "1.5"},
+		{"1.5E3", "1500"},
+		{" 12345678901234567890123 ", "12345678901234567890123"},
+	}
+	for _, test := range tests {
+		d, err := parseDecimal(test.s)
+		if err != nil {
+			t.Errorf("parseDecimal(%q): %v", test.s, err)
+			continue
+		}
+		if d.String() != test.want {
+			t.Errorf("parseDecimal(%q) = %s, want %s", test.s, d, test.want)
+		}
+		if got := numericCell(d).decimal(); got.String() != test.want {
+			t.Errorf("numericCell(%s) decodes as %s", test.want, got)
+		}
+	}
+
+	for _, s := range []string{"", ".", "-", "1.2.3", "1e", "abc", "--1", "1/3"} {
+		if _, err := parseDecimal(s); !errors.Is(err, ErrInvalidCell) {
+			t.Errorf("parseDecimal(%q): got error %v, want %v", s, err, ErrInvalidCell)
+		}
+	}
+}
+
+func TestRatDecimal(t *testing.T) {
+	// halves are rounded away from zero
+	tests := []struct {
+		num, denom int64
+		scale      int32
+		want       string
+	}{
+		{1, 3, 4, "0.3333"},
+		{2, 3, 4, "0.6667"},
+		{5, 2, 0, "3"},
+		{-5, 2, 0, "-3"},
+		{-7, 2, 1, "-3.5"},
+		{1, 200, 2, "0.01"},
+		{-1, 200, 2, "-0.01"},
+		{-1, 300, 2, "0.00"},
+	}
+	for _, test := range tests {
+		got := ratDecimal(big.NewRat(test.num, test.denom), test.scale)
+		if got.String() != test.want {
+			t.Errorf("ratDecimal(%d/%d, %d) = %s, want %s", test.num, test.denom, test.scale, got, test.want)
+		}
+	}
+}
+
+func TestNumericColumns(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "CREATE TABLE bad (a numeric(0));", err: ErrInvalidDatatype},
+		{sql: "CREATE TABLE bad (a numeric(3, 4));", err: ErrInvalidDatatype},
+		{sql: "CREATE TABLE bills (id int, amount numeric(6, 2), rate decimal(3), raw numeric);"},
+
+		// values are rounded to the scale of their column, and must then fit
+		// its precision
+		{sql: "INSERT INTO bills VALUES (1, 10.005, 2.5, 0.10), (2, -0.005, -2.5, 1e2), (3, '9999.99', 999, 12345678901234567890.5);"},
+		{sql: "SELECT id, amount, rate, raw FROM bills ORDER BY id;", rows: []string{
+			"1, 10.01, 3, 0.10",
+			"2, -0.01, -3, 100",
+			"3, 9999.99, 999, 12345678901234567890.5",
+		}},
+		{sql: "INSERT INTO bills (id, amount) VALUES (4, 9999.995);", err: ErrNumericOverflow},
+		{sql: "INSERT INTO bills (id, rate) VALUES (4, 999.5);", err: ErrNumericOverflow},
+		{sql: "INSERT INTO bills (id, amount) VALUES (4, 'abc');", err: ErrValueTypeMismatch},
+		{sql: "UPDATE bills SET amount = amount * 10;", err: ErrNumericOverflow},
+		{sql: "UPDATE bills SET amount = amount / 3 WHERE id = 1;"},
+		{sql: "INSERT INTO bills (id, amount) SELECT 5, 1.234;"},
+		{sql: "SELECT amount FROM bills WHERE id IN (1, 5) ORDER BY id;", rows: []string{"3.34", "1.23"}},
+
+		// aggregates are exact
+		{sql: "SELECT sum(amount), max(raw), min(rate) FROM bills;", rows: []string{"10004.55, 12345678901234567890.5, -3"}},
+		{sql: "SELECT avg(amount) FROM bills WHERE id IN (1, 5);", rows: []string{"2.285000000000000"}},
+
+		{sql: "CREATE TABLE d (a numeric(5, 2) DEFAULT 1234.567, b int);"},
+		{sql: "INSERT INTO d (b) VALUES (1);", err: ErrNumericOverflow},
+	})
+}
+
+func TestNumericArithmetic(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "SELECT 0.1 + 0.2, 0.1 + 0.2 = 0.3, 2 * 1.50, 1.50 - 2, 7 % 2.5, -7.5 % 2, -1.5;", rows: []string{"0.3, true, 3.00, -0.50, 2.0, -1.5, -1.5"}},
+		{sql: "SELECT 1 / 3.0, 10 / 4.0, 0.001 / 3;", rows: []string{"0.3333333333333333, 2.500000000000000, 0.0003333333333333333"}},
+		{sql: "SELECT 1.5 / 0;", err: ErrDivisionByZero},
+		{sql: "SELECT 1.5 % 0;", err: ErrDivisionByZero},
+
+		// integers too large for an int are numerics
+		{sql: "SELECT 9223372036854775807, 9223372036854775808, 99999999999999999999 + 1;", rows: []string{"9223372036854775807, 9223372036854775808, 100000000000000000000"}},
+		{sql: "SELECT 1 < 1.5, 1.5 < 2.0, 2 = 2.00;", rows: []string{"true, true, true"}},
+
+		// numerics combined with floats are floats
+		{sql: "CREATE TABLE f (x double precision);"},
+		{sql: "INSERT INTO f VALUES (1.5);"},
+		{sql: "SELECT x + 0.25, x * 2 FROM f;", rows: []string{"1.75, 3"}},
+	})
+}
+
+func TestNumericKeys(t *testing.T) {
+	runSQL(t, []sqlTest{
+		// numerics that compare equal group, join and collide alike
+		{sql: "CREATE TABLE n (v numeric, w numeric(5,2));"},
+		{sql: "INSERT INTO n VALUES (1.5, 1.5), (1.50, 2);"},
+		{sql: "SELECT count(*) FROM n GROUP BY v;", rows: []string{"2"}},
+		{sql: "SELECT count(*) FROM n a JOIN n b ON a.v = b.w;", rows: []string{"2"}},
+		{sql: "SELECT DISTINCT v FROM n;", rows: []string{"1.5"}},
+		{sql: "CREATE TABLE u (v numeric UNIQUE);"},
+		{sql: "INSERT INTO u VALUES (1.5), (1.50);", err: ErrUniqueViolation},
+	})
+}
@@ -85,12 +85,47 @@ func parseStatement(tokens []*token, initialCursor uint, delimiter token) (*Stat
 		}, newCursor, true
 	}
 
+	if upd, newCursor, ok := parseUpdateStatement(tokens, cursor, tokenFromSymbol(SEMICOLON)); ok {
+		return &Statement{
+			Kind:            UpdateStmtKind,
+			UpdateStatement: upd,
+		}, newCursor, true
+	}
+
+	if del, newCursor, ok := parseDeleteStatement(tokens, cursor, tokenFromSymbol(SEMICOLON)); ok {
+		return &Statement{
+			Kind:            DeleteStmtKind,
+			DeleteStatement: del,
+		}, newCursor, true
+	}
+
+	if del, newCursor, ok := parseTruncateStatement(tokens, cursor); ok {
+		return &Statement{
+			Kind:            DeleteStmtKind,
+			DeleteStatement: del,
+		}, newCursor, true
+	}
+
 	if crt, newCursor, ok := parseCreateTableStatement(tokens, cursor, tokenFromSymbol(SEMICOLON)); ok {
 		return &Statement{
 			Kind:            CreateStmtKind,
 			CreateStatement: crt,
 		}, newCursor, true
 	}
+
+	if alt, newCursor, ok := parseAlterTableStatement(tokens, cursor); ok {
+		return &Statement{
+			Kind:           AlterStmtKind,
+			AlterStatement: alt,
+		}, newCursor, true
+	}
+
+	if drp, newCursor, ok := parseDropTableStatement(tokens, cursor); ok {
+		return &Statement{
+			Kind:          DropStmtKind,
+			DropStatement: drp,
+		}, newCursor, true
+	}
 	return nil, initialCursor, false
 }
 
@@ -107,7 +142,23 @@ func parseCreateTableStatement(tokens []*token, initialCursor uint, delimiter to
 	}
 	cursor++
 
-	name, newCursor, ok := parseToken(tokens, cursor, IDENTIFIER)
+	ifNotExists := false
+	if expectToken(tokens, cursor, tokenFromKeyword(IF)) {
+		cursor++
+		if !expectToken(tokens, cursor, tokenFromKeyword(NOT)) {
+			helpMessage(tokens, cursor, "Expected NOT")
+			return nil, initialCursor, false
+		}
+		cursor++
+		if !expectToken(tokens, cursor, tokenFromKeyword(EXISTS)) {
+			helpMessage(tokens, cursor, "Expected EXISTS")
+			return nil, initialCursor, false
+		}
+		cursor++
+		ifNotExists = true
+	}
+
+	name, newCursor, ok := parseIdentifier(tokens, cursor)
 	if !ok {
 		helpMessage(tokens, cursor, "Expected Table name")
 		return nil, initialCursor, false
@@ -120,7 +171,7 @@ func parseCreateTableStatement(tokens []*token, initialCursor uint, delimiter to
 	}
 	cursor++
 
-	cols, newCursor, ok := parseColumnDefinitions(tokens, cursor, tokenFromSymbol(RIGHTPAREN))
+	cols, constraints, newCursor, ok := parseColumnDefinitions(tokens, cursor, tokenFromSymbol(RIGHTPAREN))
 	if !ok {
 		helpMessage(tokens, cursor, "Invalid column definition")
 		return nil, initialCursor, false
@@ -134,171 +185,318 @@ func parseCreateTableStatement(tokens []*token, initialCursor uint, delimiter to
 	cursor++
 
 	return &CreateStatement{
-		name: *name,
-		cols: cols,
+		name:        *name,
+		cols:        cols,
+		constraints: constraints,
+		ifNotExists: ifNotExists,
 	}, cursor, true
 }
 
-func parseColumnDefinitions(tokens []*token, initialCursor uint, delimiter token) (*[]*columnDefinition, uint, bool) {
+func parseAlterTableStatement(tokens []*token, initialCursor uint) (*AlterStatement, uint, bool) {
 	cursor := initialCursor
 
-	var cds []*columnDefinition
-	for {
-		if cursor >= uint(len(tokens)) {
-			return nil, initialCursor, false
-		}
+	if !expectToken(tokens, cursor, tokenFromKeyword(ALTER)) {
+		return nil, initialCursor, false
+	}
+	cursor++
 
-		current := tokens[cursor]
-		if delimiter.equals(current) {
-			break
+	if !expectToken(tokens, cursor, tokenFromKeyword(TABLE)) {
+		helpMessage(tokens, cursor, "Expected TABLE")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	name, newCursor, ok := parseIdentifier(tokens, cursor)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected table name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+	alt := AlterStatement{table: *name}
+
+	switch {
+	case expectToken(tokens, cursor, tokenFromKeyword(ADD)):
+		cursor++
+		if expectToken(tokens, cursor, tokenFromKeyword(COLUMN)) {
+			cursor++
 		}
 
-		if len(cds) > 0 {
-			if !expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
-				helpMessage(tokens, cursor, "Expected comma")
-				return nil, initialCursor, false
-			}
+		column, newCursor, ok := parseColumnDefinition(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		alt.kind = addColumnAlterKind
+		alt.column = column
+		cursor = newCursor
+	case expectToken(tokens, cursor, tokenFromKeyword(DROP)):
+		cursor++
+		if expectToken(tokens, cursor, tokenFromKeyword(COLUMN)) {
 			cursor++
 		}
 
-		id, newCursor, ok := parseToken(tokens, cursor, IDENTIFIER)
+		column, newCursor, ok := parseIdentifier(tokens, cursor)
 		if !ok {
 			helpMessage(tokens, cursor, "Expected column name")
 			return nil, initialCursor, false
 		}
+		alt.kind = dropColumnAlterKind
+		alt.name = column
 		cursor = newCursor
+	case expectToken(tokens, cursor, tokenFromKeyword(RENAME)):
+		cursor++
+		alt.kind = renameTableAlterKind
+
+		// RENAME TO renames the table, RENAME [COLUMN] a TO b a column
+		if !expectToken(tokens, cursor, tokenFromKeyword(TO)) {
+			if expectToken(tokens, cursor, tokenFromKeyword(COLUMN)) {
+				cursor++
+			}
+
+			column, newCursor, ok := parseIdentifier(tokens, cursor)
+			if !ok {
+				helpMessage(tokens, cursor, "Expected column name")
+				return nil, initialCursor, false
+			}
+			alt.kind = renameColumnAlterKind
+			alt.name = column
+			cursor = newCursor
+		}
+
+		if !expectToken(tokens, cursor, tokenFromKeyword(TO)) {
+			helpMessage(tokens, cursor, "Expected TO")
+			return nil, initialCursor, false
+		}
+		cursor++
 
-		ty, newCursor, ok := parseToken(tokens, cursor, KEYWORD)
+		newName, newCursor, ok := parseIdentifier(tokens, cursor)
 		if !ok {
-			helpMessage(tokens, cursor, "Expected column type")
+			helpMessage(tokens, cursor, "Expected new name")
 			return nil, initialCursor, false
 		}
+		alt.newName = newName
 		cursor = newCursor
-
-		cds = append(cds, &columnDefinition{
-			name:     *id,
-			datatype: *ty,
-		})
+	default:
+		helpMessage(tokens, cursor, "Expected ADD, DROP or RENAME")
+		return nil, initialCursor, false
 	}
-	return &cds, cursor, true
+
+	return &alt, cursor, true
 }
 
-func parseSelectStatement(tokens []*token, initialCursor uint, delimiter token) (*SelectStatement, uint, bool) {
+func parseDropTableStatement(tokens []*token, initialCursor uint) (*DropStatement, uint, bool) {
 	cursor := initialCursor
 
-	if !expectToken(tokens, cursor, tokenFromKeyword(SELECT)) {
+	if !expectToken(tokens, cursor, tokenFromKeyword(DROP)) {
 		return nil, initialCursor, false
 	}
 	cursor++
-	slct := SelectStatement{}
 
-	exps, newCursor, ok := parseExpressions(tokens, cursor, []token{tokenFromKeyword(FROM), delimiter})
-	if !ok {
+	if !expectToken(tokens, cursor, tokenFromKeyword(TABLE)) {
+		helpMessage(tokens, cursor, "Expected TABLE")
 		return nil, initialCursor, false
 	}
+	cursor++
 
-	slct.item = *exps
-	cursor = newCursor
-
-	if expectToken(tokens, cursor, tokenFromKeyword(FROM)) {
+	drp := DropStatement{}
+	if expectToken(tokens, cursor, tokenFromKeyword(IF)) {
 		cursor++
-		from, newCursor, ok := parseToken(tokens, cursor, IDENTIFIER)
-		if !ok {
-			helpMessage(tokens, cursor, "Expected FROM token")
+		if !expectToken(tokens, cursor, tokenFromKeyword(EXISTS)) {
+			helpMessage(tokens, cursor, "Expected EXISTS")
 			return nil, initialCursor, false
 		}
-		slct.from = *from
-		cursor = newCursor
+		cursor++
+		drp.ifExists = true
 	}
-	return &slct, cursor, true
-}
 
-func parseToken(tokens []*token, initialCursor uint, kind tokenKind) (*token, uint, bool) {
-	cursor := initialCursor
+	for {
+		name, newCursor, ok := parseIdentifier(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected table name")
+			return nil, initialCursor, false
+		}
+		drp.names = append(drp.names, *name)
+		cursor = newCursor
 
-	if cursor >= uint(len(tokens)) {
-		return nil, initialCursor, false
-	}
-	current := tokens[cursor]
-	if current.kind == kind {
-		return current, cursor + 1, true
+		if !expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
+			break
+		}
+		cursor++
 	}
-	return nil, initialCursor, false
+
+	return &drp, cursor, true
 }
 
-func parseExpressions(tokens []*token, initialCursor uint, delimiters []token) (*[]*expression, uint, bool) {
+// parseColumnDefinitions parses the column definitions of a CREATE TABLE and
+// the table constraints among them
+func parseColumnDefinitions(tokens []*token, initialCursor uint, delimiter token) (*[]*columnDefinition, []*tableConstraint, uint, bool) {
 	cursor := initialCursor
-	var exps []*expression
 
-outer:
+	var cds []*columnDefinition
+	var constraints []*tableConstraint
 	for {
 		if cursor >= uint(len(tokens)) {
-			return nil, initialCursor, false
+			return nil, nil, initialCursor, false
 		}
 
 		current := tokens[cursor]
-		for _, delimiter := range delimiters {
-			if delimiter.equals(current) {
-				break outer
-			}
+		if delimiter.equals(current) {
+			break
 		}
-		if len(exps) > 0 {
+
+		if len(cds) > 0 || len(constraints) > 0 {
 			if !expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
 				helpMessage(tokens, cursor, "Expected comma")
-				return nil, initialCursor, false
+				return nil, nil, initialCursor, false
 			}
 			cursor++
 		}
 
-		exp, newCursor, ok := parseExpression(tokens, cursor, tokenFromSymbol(COMMA))
+		if constraint, newCursor, ok := parseTableConstraint(tokens, cursor); ok {
+			cursor = newCursor
+			constraints = append(constraints, constraint)
+			continue
+		}
+
+		cd, newCursor, ok := parseColumnDefinition(tokens, cursor)
 		if !ok {
-			helpMessage(tokens, cursor, "Expected expression")
-			return nil, initialCursor, false
+			return nil, nil, initialCursor, false
 		}
 		cursor = newCursor
-
-		exps = append(exps, exp)
+		cds = append(cds, cd)
 	}
-	return &exps, cursor, true
+	return &cds, constraints, cursor, true
 }
 
-func parseExpression(tokens []*token, initialCursor uint, _ token) (*expression, uint, bool) {
+// parseTableConstraint parses PRIMARY KEY (columns), UNIQUE (columns) or
+// CHECK (predicate)
+func parseTableConstraint(tokens []*token, initialCursor uint) (*tableConstraint, uint, bool) {
 	cursor := initialCursor
+	constraint := tableConstraint{}
 
-	kinds := []tokenKind{IDENTIFIER, NUMERIC, STRING}
-	for _, kind := range kinds {
-		t, newCursor, ok := parseToken(tokens, cursor, kind)
-		if ok {
-			return &expression{
-				literal: t,
-				kind:    literalKind,
-			}, newCursor, true
+	switch {
+	case expectToken(tokens, cursor, tokenFromKeyword(PRIMARY)):
+		cursor++
+		if !expectToken(tokens, cursor, tokenFromKeyword(KEY)) {
+			helpMessage(tokens, cursor, "Expected KEY")
+			return nil, initialCursor, false
+		}
+		cursor++
+		constraint.kind = primaryKeyConstraintKind
+	case expectToken(tokens, cursor, tokenFromKeyword(UNIQUE)):
+		cursor++
+		constraint.kind = uniqueConstraintKind
+	case expectToken(tokens, cursor, tokenFromKeyword(FOREIGN)):
+		cursor++
+		if !expectToken(tokens, cursor, tokenFromKeyword(KEY)) {
+			helpMessage(tokens, cursor, "Expected KEY")
+			return nil, initialCursor, false
+		}
+		cursor++
+
+		columns, newCursor, ok := parseColumnNames(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column names")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		ref, newCursor, ok := parseReferences(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected REFERENCES")
+			return nil, initialCursor, false
+		}
+		constraint.kind = foreignKeyConstraintKind
+		constraint.columns = *columns
+		constraint.references = ref
+		return &constraint, newCursor, true
+	case expectToken(tokens, cursor, tokenFromKeyword(CHECK)):
+		check, newCursor, ok := parseCheck(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
 		}
+		constraint.kind = checkConstraintKind
+		constraint.check = check
+		return &constraint, newCursor, true
+	default:
+		return nil, initialCursor, false
 	}
 
-	return nil, initialCursor, false
+	columns, newCursor, ok := parseColumnNames(tokens, cursor)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected column names")
+		return nil, initialCursor, false
+	}
+	constraint.columns = *columns
+	return &constraint, newCursor, true
 }
 
-func parseInsertStatement(tokens []*token, initialCursor uint, delimiter token) (*InsertStatement, uint, bool) {
+// parseReferences parses REFERENCES table [(columns)] followed by the ON
+// DELETE and ON UPDATE actions in either order
+func parseReferences(tokens []*token, initialCursor uint) (*references, uint, bool) {
 	cursor := initialCursor
-	if !expectToken(tokens, cursor, tokenFromKeyword(INSERT)) {
-		return nil, initialCursor, false
-	}
-	cursor++
-	if !expectToken(tokens, cursor, tokenFromKeyword(INTO)) {
+	if !expectToken(tokens, cursor, tokenFromKeyword(REFERENCES)) {
 		return nil, initialCursor, false
 	}
 	cursor++
 
-	table, newCursor, ok := parseToken(tokens, cursor, IDENTIFIER)
+	table, newCursor, ok := parseIdentifier(tokens, cursor)
 	if !ok {
 		helpMessage(tokens, cursor, "Expected table name")
 		return nil, initialCursor, false
 	}
 	cursor = newCursor
+	ref := references{table: *table}
 
-	if !expectToken(tokens, cursor, tokenFromKeyword(VALUES)) {
-		helpMessage(tokens, cursor, "Expected VALUES")
+	if expectToken(tokens, cursor, tokenFromSymbol(LEFTPAREN)) {
+		columns, newCursor, ok := parseColumnNames(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		ref.columns = *columns
+		cursor = newCursor
+	}
+
+	for expectToken(tokens, cursor, tokenFromKeyword(ON)) {
+		cursor++
+
+		action := &ref.onDelete
+		if expectToken(tokens, cursor, tokenFromKeyword(UPDATE)) {
+			action = &ref.onUpdate
+		} else if !expectToken(tokens, cursor, tokenFromKeyword(DELETE)) {
+			helpMessage(tokens, cursor, "Expected DELETE or UPDATE")
+			return nil, initialCursor, false
+		}
+		cursor++
+
+		switch {
+		case expectToken(tokens, cursor, tokenFromKeyword(RESTRICT)):
+			*action = restrictAction
+		case expectToken(tokens, cursor, tokenFromKeyword(CASCADE)):
+			*action = cascadeAction
+		case expectToken(tokens, cursor, tokenFromKeyword(SET)):
+			cursor++
+			if expectToken(tokens, cursor, tokenFromKeyword(NULL)) {
+				*action = setNullAction
+			} else if expectToken(tokens, cursor, tokenFromKeyword(DEFAULT)) {
+				*action = setDefaultAction
+			} else {
+				helpMessage(tokens, cursor, "Expected NULL or DEFAULT")
+				return nil, initialCursor, false
+			}
+		default:
+			helpMessage(tokens, cursor, "Expected RESTRICT, CASCADE, SET NULL or SET DEFAULT")
+			return nil, initialCursor, false
+		}
+		cursor++
+	}
+
+	return &ref, cursor, true
+}
+
+// parseCheck parses CHECK (predicate)
+func parseCheck(tokens []*token, initialCursor uint) (*expression, uint, bool) {
+	cursor := initialCursor
+	if !expectToken(tokens, cursor, tokenFromKeyword(CHECK)) {
 		return nil, initialCursor, false
 	}
 	cursor++
@@ -309,8 +507,9 @@ func parseInsertStatement(tokens []*token, initialCursor uint, delimiter token)
 	}
 	cursor++
 
-	values, newCursor, ok := parseExpressions(tokens, cursor, []token{tokenFromSymbol(RIGHTPAREN)})
+	check, newCursor, ok := parseExpression(tokens, cursor, tokenFromSymbol(RIGHTPAREN))
 	if !ok {
+		helpMessage(tokens, cursor, "Expected CHECK predicate")
 		return nil, initialCursor, false
 	}
 	cursor = newCursor
@@ -319,10 +518,1338 @@ func parseInsertStatement(tokens []*token, initialCursor uint, delimiter token)
 		helpMessage(tokens, cursor, "Expected right paren")
 		return nil, initialCursor, false
 	}
-	cursor++
+	return check, cursor + 1, true
+}
 
-	return &InsertStatement{
-		table:  *table,
-		values: values,
-	}, cursor, true
+// parseDatatype parses the name of a type, returning the keywords naming it
+// as one token, e.g. "timestamp with time zone"
+func parseDatatype(tokens []*token, initialCursor uint) (*token, uint, bool) {
+	cursor := initialCursor
+
+	ty, newCursor, ok := parseToken(tokens, cursor, KEYWORD)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	switch keyword(ty.value) {
+	case DOUBLE:
+		if !expectToken(tokens, cursor, tokenFromKeyword(PRECISION)) {
+			helpMessage(tokens, cursor, "Expected PRECISION")
+			return nil, initialCursor, false
+		}
+		cursor++
+		ty = &token{value: "double precision", kind: KEYWORD, loc: ty.loc}
+	case BLOB:
+		ty = &token{value: string(BYTEA), kind: KEYWORD, loc: ty.loc}
+	case NUMERICKW, DECIMAL:
+		// the precision and scale are part of the name of the type, e.g.
+		// numeric(10,2), the scale being 0 if only the precision is given
+		name := string(NUMERICKW)
+		if expectToken(tokens, cursor, tokenFromSymbol(LEFTPAREN)) {
+			precision, newCursor, ok := parseToken(tokens, cursor+1, NUMERIC)
+			if !ok {
+				helpMessage(tokens, cursor+1, "Expected precision")
+				return nil, initialCursor, false
+			}
+			cursor = newCursor
+
+			scale := "0"
+			if expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
+				s, newCursor, ok := parseToken(tokens, cursor+1, NUMERIC)
+				if !ok {
+					helpMessage(tokens, cursor+1, "Expected scale")
+					return nil, initialCursor, false
+				}
+				cursor = newCursor
+				scale = s.value
+			}
+
+			if !expectToken(tokens, cursor, tokenFromSymbol(RIGHTPAREN)) {
+				helpMessage(tokens, cursor, "Expected )")
+				return nil, initialCursor, false
+			}
+			cursor++
+			name = fmt.Sprintf("%s(%s,%s)", name, precision.value, scale)
+		}
+		ty = &token{value: name, kind: KEYWORD, loc: ty.loc}
+	case TIME, TIMESTAMP:
+		// WITHOUT TIME ZONE is the default
+		with := expectToken(tokens, cursor, tokenFromKeyword(WITH))
+		if !with && !expectToken(tokens, cursor, tokenFromKeyword(WITHOUT)) {
+			break
+		}
+		cursor++
+		if !expectToken(tokens, cursor, tokenFromKeyword(TIME)) || !expectToken(tokens, cursor+1, tokenFromKeyword(ZONE)) {
+			helpMessage(tokens, cursor, "Expected TIME ZONE")
+			return nil, initialCursor, false
+		}
+		cursor += 2
+		if with {
+			ty = &token{value: ty.value + " with time zone", kind: KEYWORD, loc: ty.loc}
+		}
+	}
+	return ty, cursor, true
+}
+
+// parseColumnDefinition parses a column name, its type and the constraints
+// following them in any order
+func parseColumnDefinition(tokens []*token, initialCursor uint) (*columnDefinition, uint, bool) {
+	cursor := initialCursor
+
+	id, newCursor, ok := parseIdentifier(tokens, cursor)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected column name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	ty, newCursor, ok := parseDatatype(tokens, cursor)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected column type")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	cd := columnDefinition{
+		name:     *id,
+		datatype: *ty,
+	}
+
+	for {
+		switch {
+		case expectToken(tokens, cursor, tokenFromKeyword(DEFAULT)):
+			cursor++
+			def, newCursor, ok := parseExpression(tokens, cursor, tokenFromSymbol(COMMA))
+			if !ok {
+				helpMessage(tokens, cursor, "Expected default value")
+				return nil, initialCursor, false
+			}
+			cd.def = def
+			cursor = newCursor
+		case expectToken(tokens, cursor, tokenFromKeyword(NOT)):
+			cursor++
+			if !expectToken(tokens, cursor, tokenFromKeyword(NULL)) {
+				helpMessage(tokens, cursor, "Expected NULL")
+				return nil, initialCursor, false
+			}
+			cursor++
+			cd.notNull = true
+		case expectToken(tokens, cursor, tokenFromKeyword(NULL)):
+			// columns are nullable unless declared otherwise
+			cursor++
+		case expectToken(tokens, cursor, tokenFromKeyword(UNIQUE)):
+			cursor++
+			cd.unique = true
+		case expectToken(tokens, cursor, tokenFromKeyword(PRIMARY)):
+			cursor++
+			if !expectToken(tokens, cursor, tokenFromKeyword(KEY)) {
+				helpMessage(tokens, cursor, "Expected KEY")
+				return nil, initialCursor, false
+			}
+			cursor++
+			cd.primaryKey = true
+		case expectToken(tokens, cursor, tokenFromKeyword(CHECK)):
+			check, newCursor, ok := parseCheck(tokens, cursor)
+			if !ok {
+				return nil, initialCursor, false
+			}
+			cd.check = check
+			cursor = newCursor
+		case expectToken(tokens, cursor, tokenFromKeyword(REFERENCES)):
+			ref, newCursor, ok := parseReferences(tokens, cursor)
+			if !ok {
+				return nil, initialCursor, false
+			}
+			cd.references = ref
+			cursor = newCursor
+		default:
+			return &cd, cursor, true
+		}
+	}
+}
+
+func parseSelectStatement(tokens []*token, initialCursor uint, delimiter token) (*SelectStatement, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(SELECT)) {
+		return nil, initialCursor, false
+	}
+
+	slct, newCursor, ok := parseSetOperation(tokens, cursor, delimiter, 0)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if expectToken(tokens, cursor, tokenFromKeyword(ORDER)) {
+		cursor++
+		if !expectToken(tokens, cursor, tokenFromKeyword(BY)) {
+			helpMessage(tokens, cursor, "Expected BY")
+			return nil, initialCursor, false
+		}
+		cursor++
+
+		orderBy, newCursor, ok := parseOrderByItems(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		slct.orderBy = *orderBy
+		cursor = newCursor
+	}
+
+	// LIMIT and OFFSET may come in either order
+	for i := 0; i < 2; i++ {
+		if slct.limit == nil && expectToken(tokens, cursor, tokenFromKeyword(LIMIT)) {
+			cursor++
+			limit, newCursor, ok := parseExpression(tokens, cursor, delimiter)
+			if !ok {
+				helpMessage(tokens, cursor, "Expected LIMIT value")
+				return nil, initialCursor, false
+			}
+			slct.limit = limit
+			cursor = newCursor
+		} else if slct.offset == nil && expectToken(tokens, cursor, tokenFromKeyword(OFFSET)) {
+			cursor++
+			offset, newCursor, ok := parseExpression(tokens, cursor, delimiter)
+			if !ok {
+				helpMessage(tokens, cursor, "Expected OFFSET value")
+				return nil, initialCursor, false
+			}
+			slct.offset = offset
+			cursor = newCursor
+		}
+	}
+	return slct, cursor, true
+}
+
+// parseFromItems parses the comma separated FROM items, each pair of which
+// is cross joined. JOINs bind tighter than commas.
+func parseFromItems(tokens []*token, initialCursor uint) (*fromItem, uint, bool) {
+	cursor := initialCursor
+
+	var from *fromItem
+	for {
+		if from != nil {
+			if !expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
+				break
+			}
+			cursor++
+		}
+
+		item, newCursor, ok := parseJoins(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		if from == nil {
+			from = item
+			continue
+		}
+		from = &fromItem{
+			join: &joinClause{
+				kind:  crossJoinKind,
+				left:  from,
+				right: item,
+			},
+			kind: joinFromKind,
+		}
+	}
+	return from, cursor, true
+}
+
+// parseJoinKind parses the keywords introducing a JOIN
+func parseJoinKind(tokens []*token, initialCursor uint) (joinKind, uint, bool) {
+	cursor := initialCursor
+
+	kind := innerJoinKind
+	outer := false
+	if expectToken(tokens, cursor, tokenFromKeyword(INNER)) {
+		cursor++
+	} else if expectToken(tokens, cursor, tokenFromKeyword(CROSS)) {
+		kind = crossJoinKind
+		cursor++
+	} else if expectToken(tokens, cursor, tokenFromKeyword(LEFT)) {
+		kind = leftJoinKind
+		outer = true
+		cursor++
+	} else if expectToken(tokens, cursor, tokenFromKeyword(RIGHT)) {
+		kind = rightJoinKind
+		outer = true
+		cursor++
+	} else if expectToken(tokens, cursor, tokenFromKeyword(FULL)) {
+		kind = fullJoinKind
+		outer = true
+		cursor++
+	}
+
+	if outer && expectToken(tokens, cursor, tokenFromKeyword(OUTER)) {
+		cursor++
+	}
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(JOIN)) {
+		return 0, initialCursor, false
+	}
+	return kind, cursor + 1, true
+}
+
+// parseJoins parses a table reference followed by any number of JOINs,
+// which associate to the left
+func parseJoins(tokens []*token, initialCursor uint) (*fromItem, uint, bool) {
+	cursor := initialCursor
+
+	from, newCursor, ok := parseTableReference(tokens, cursor)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	for {
+		kind, newCursor, ok := parseJoinKind(tokens, cursor)
+		if !ok {
+			break
+		}
+		cursor = newCursor
+
+		right, newCursor, ok := parseTableReference(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected table to join")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+		join := joinClause{
+			kind:  kind,
+			left:  from,
+			right: right,
+		}
+
+		if kind != crossJoinKind {
+			if expectToken(tokens, cursor, tokenFromKeyword(ON)) {
+				cursor++
+				on, newCursor, ok := parseExpression(tokens, cursor, tokenFromSymbol(COMMA))
+				if !ok {
+					helpMessage(tokens, cursor, "Expected join condition")
+					return nil, initialCursor, false
+				}
+				join.on = on
+				cursor = newCursor
+			} else if expectToken(tokens, cursor, tokenFromKeyword(USING)) {
+				cursor++
+				using, newCursor, ok := parseColumnNames(tokens, cursor)
+				if !ok {
+					helpMessage(tokens, cursor, "Expected USING columns")
+					return nil, initialCursor, false
+				}
+				join.using = *using
+				cursor = newCursor
+			} else {
+				helpMessage(tokens, cursor, "Expected ON or USING")
+				return nil, initialCursor, false
+			}
+		}
+
+		from = &fromItem{
+			join: &join,
+			kind: joinFromKind,
+		}
+	}
+	return from, cursor, true
+}
+
+// parseTableReference parses a table name or a parenthesised subquery with
+// an optional alias
+func parseTableReference(tokens []*token, initialCursor uint) (*fromItem, uint, bool) {
+	cursor := initialCursor
+	var item fromItem
+
+	if slct, newCursor, ok := parseSubquery(tokens, cursor); ok {
+		item.subquery = slct
+		item.kind = subqueryFromKind
+		cursor = newCursor
+	} else {
+		table, newCursor, ok := parseIdentifier(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		item.table = table
+		item.kind = tableFromKind
+		cursor = newCursor
+	}
+
+	alias, newCursor, ok := parseAlias(tokens, cursor)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	item.alias = alias
+	return &item, newCursor, true
+}
+
+// parseAlias parses an optional alias, the AS keyword before it being
+// optional too
+func parseAlias(tokens []*token, initialCursor uint) (*token, uint, bool) {
+	cursor := initialCursor
+
+	if expectToken(tokens, cursor, tokenFromKeyword(AS)) {
+		cursor++
+		as, newCursor, ok := parseIdentifier(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected alias")
+			return nil, initialCursor, false
+		}
+		return as, newCursor, true
+	}
+	if as, newCursor, ok := parseToken(tokens, cursor, IDENTIFIER); ok {
+		return as, newCursor, true
+	}
+	return nil, initialCursor, true
+}
+
+// parseColumnNames parses a parenthesised, comma separated list of column
+// names
+func parseColumnNames(tokens []*token, initialCursor uint) (*[]token, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(LEFTPAREN)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	var names []token
+	for {
+		if len(names) > 0 {
+			if !expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
+				break
+			}
+			cursor++
+		}
+
+		name, newCursor, ok := parseIdentifier(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected column name")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		names = append(names, *name)
+	}
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(RIGHTPAREN)) {
+		helpMessage(tokens, cursor, "Expected right paren")
+		return nil, initialCursor, false
+	}
+	return &names, cursor + 1, true
+}
+
+// setOperatorPower returns the binding power of a set operator, zero if the
+// token is not one. INTERSECT binds tighter than UNION and EXCEPT.
+func setOperatorPower(t *token) uint {
+	if t.kind != KEYWORD {
+		return 0
+	}
+	switch keyword(t.value) {
+	case UNION, EXCEPT:
+		return 1
+	case INTERSECT:
+		return 2
+	}
+	return 0
+}
+
+// parseSetOperation parses selects combined by set operators using
+// precedence climbing, only consuming operators that bind tighter than
+// minPower
+func parseSetOperation(tokens []*token, initialCursor uint, delimiter token, minPower uint) (*SelectStatement, uint, bool) {
+	cursor := initialCursor
+
+	slct, newCursor, ok := parseSelectCore(tokens, cursor, delimiter)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	for cursor < uint(len(tokens)) {
+		op := tokens[cursor]
+		power := setOperatorPower(op)
+		if power == 0 || power <= minPower {
+			break
+		}
+		cursor++
+
+		all := false
+		if expectToken(tokens, cursor, tokenFromKeyword(ALL)) {
+			all = true
+			cursor++
+		} else if expectToken(tokens, cursor, tokenFromKeyword(DISTINCT)) {
+			cursor++
+		}
+
+		right, newCursor, ok := parseSetOperation(tokens, cursor, delimiter, power)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected SELECT")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		slct = &SelectStatement{
+			setOp: &setOperation{
+				op:    *op,
+				all:   all,
+				left:  slct,
+				right: right,
+			},
+		}
+	}
+	return slct, cursor, true
+}
+
+// parseSelectCore parses a single SELECT up to its HAVING clause, the
+// clauses following it apply to the whole set operation it may be part of
+func parseSelectCore(tokens []*token, initialCursor uint, delimiter token) (*SelectStatement, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(SELECT)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+	slct := SelectStatement{}
+
+	if expectToken(tokens, cursor, tokenFromKeyword(DISTINCT)) {
+		slct.distinct = true
+		cursor++
+	} else if expectToken(tokens, cursor, tokenFromKeyword(ALL)) {
+		cursor++
+	}
+
+	items, newCursor, ok := parseSelectItems(tokens, cursor)
+	if !ok {
+		return nil, initialCursor, false
+	}
+
+	slct.item = *items
+	cursor = newCursor
+
+	if expectToken(tokens, cursor, tokenFromKeyword(FROM)) {
+		cursor++
+		from, newCursor, ok := parseFromItems(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected FROM items")
+			return nil, initialCursor, false
+		}
+		slct.from = from
+		cursor = newCursor
+	}
+
+	if expectToken(tokens, cursor, tokenFromKeyword(WHERE)) {
+		cursor++
+		where, newCursor, ok := parseExpression(tokens, cursor, delimiter)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected WHERE conditionals")
+			return nil, initialCursor, false
+		}
+		slct.where = where
+		cursor = newCursor
+	}
+
+	if expectToken(tokens, cursor, tokenFromKeyword(GROUP)) {
+		cursor++
+		if !expectToken(tokens, cursor, tokenFromKeyword(BY)) {
+			helpMessage(tokens, cursor, "Expected BY")
+			return nil, initialCursor, false
+		}
+		cursor++
+
+		groupBy, newCursor, ok := parseExpressionList(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected GROUP BY expressions")
+			return nil, initialCursor, false
+		}
+		slct.groupBy = *groupBy
+		cursor = newCursor
+	}
+
+	if expectToken(tokens, cursor, tokenFromKeyword(HAVING)) {
+		cursor++
+		having, newCursor, ok := parseExpression(tokens, cursor, delimiter)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected HAVING conditionals")
+			return nil, initialCursor, false
+		}
+		slct.having = having
+		cursor = newCursor
+	}
+
+	return &slct, cursor, true
+}
+
+// parseOrderByItems parses the comma separated sort keys of an ORDER BY
+func parseOrderByItems(tokens []*token, initialCursor uint) (*[]*orderByItem, uint, bool) {
+	cursor := initialCursor
+
+	var items []*orderByItem
+	for {
+		if len(items) > 0 {
+			if !expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
+				break
+			}
+			cursor++
+		}
+
+		exp, newCursor, ok := parseExpression(tokens, cursor, tokenFromSymbol(COMMA))
+		if !ok {
+			helpMessage(tokens, cursor, "Expected sort key")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+		item := orderByItem{exp: exp}
+
+		if expectToken(tokens, cursor, tokenFromKeyword(DESC)) {
+			item.desc = true
+			cursor++
+		} else if expectToken(tokens, cursor, tokenFromKeyword(ASC)) {
+			cursor++
+		}
+
+		// NULLs sort as if larger than any value unless told otherwise
+		item.nullsFirst = item.desc
+		if expectToken(tokens, cursor, tokenFromKeyword(NULLS)) {
+			cursor++
+			if expectToken(tokens, cursor, tokenFromKeyword(FIRST)) {
+				item.nullsFirst = true
+			} else if expectToken(tokens, cursor, tokenFromKeyword(LAST)) {
+				item.nullsFirst = false
+			} else {
+				helpMessage(tokens, cursor, "Expected FIRST or LAST")
+				return nil, initialCursor, false
+			}
+			cursor++
+		}
+
+		items = append(items, &item)
+	}
+	return &items, cursor, true
+}
+
+// parseSelectItems parses the comma separated select list
+func parseSelectItems(tokens []*token, initialCursor uint) (*[]*selectItem, uint, bool) {
+	cursor := initialCursor
+
+	var items []*selectItem
+	for {
+		if len(items) > 0 {
+			if !expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
+				break
+			}
+			cursor++
+		}
+
+		item, newCursor, ok := parseSelectItem(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected select item")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		items = append(items, item)
+	}
+	return &items, cursor, true
+}
+
+func parseSelectItem(tokens []*token, initialCursor uint) (*selectItem, uint, bool) {
+	cursor := initialCursor
+
+	if expectToken(tokens, cursor, tokenFromSymbol(ASTERISK)) {
+		return &selectItem{asterisk: true}, cursor + 1, true
+	}
+
+	// table.*
+	if table, newCursor, ok := parseIdentifier(tokens, cursor); ok &&
+		expectToken(tokens, newCursor, tokenFromSymbol(DOT)) &&
+		expectToken(tokens, newCursor+1, tokenFromSymbol(ASTERISK)) {
+		return &selectItem{
+			asterisk: true,
+			table:    table,
+		}, newCursor + 2, true
+	}
+
+	exp, newCursor, ok := parseExpression(tokens, cursor, tokenFromSymbol(COMMA))
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	as, newCursor, ok := parseAlias(tokens, cursor)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	return &selectItem{
+		exp: exp,
+		as:  as,
+	}, newCursor, true
+}
+
+func parseToken(tokens []*token, initialCursor uint, kind tokenKind) (*token, uint, bool) {
+	cursor := initialCursor
+
+	if cursor >= uint(len(tokens)) {
+		return nil, initialCursor, false
+	}
+	current := tokens[cursor]
+	if current.kind == kind {
+		return current, cursor + 1, true
+	}
+	return nil, initialCursor, false
+}
+
+// unreservedKeywords can also be used as identifiers, e.g. as column names
+var unreservedKeywords = []keyword{
+	NULLS,
+	FIRST,
+	LAST,
+	KEY,
+	PRECISION,
+	DATE,
+	TIME,
+	TIMESTAMP,
+	INTERVAL,
+	ZONE,
+}
+
+// parseIdentifier parses an identifier, accepting unreserved keywords as one
+func parseIdentifier(tokens []*token, initialCursor uint) (*token, uint, bool) {
+	if t, newCursor, ok := parseToken(tokens, initialCursor, IDENTIFIER); ok {
+		return t, newCursor, true
+	}
+
+	t, newCursor, ok := parseToken(tokens, initialCursor, KEYWORD)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	for _, k := range unreservedKeywords {
+		if keyword(t.value) == k {
+			return &token{
+				value: t.value,
+				kind:  IDENTIFIER,
+				loc:   t.loc,
+			}, newCursor, true
+		}
+	}
+	return nil, initialCursor, false
+}
+
+// parseExpressionList parses one or more comma separated expressions
+func parseExpressionList(tokens []*token, initialCursor uint) (*[]*expression, uint, bool) {
+	cursor := initialCursor
+
+	var exps []*expression
+	for {
+		if len(exps) > 0 {
+			if !expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
+				break
+			}
+			cursor++
+		}
+
+		exp, newCursor, ok := parseExpression(tokens, cursor, tokenFromSymbol(COMMA))
+		if !ok {
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		exps = append(exps, exp)
+	}
+	return &exps, cursor, true
+}
+
+// binaryOperatorPower returns the binding power of a binary operator, zero
+// if the token is not one. Operators with a higher power bind tighter.
+func binaryOperatorPower(t *token) uint {
+	switch t.kind {
+	case KEYWORD:
+		switch keyword(t.value) {
+		case OR:
+			return 1
+		case AND:
+			return 2
+		}
+	case SYMBOL:
+		switch symbol(t.value) {
+		case EQUALS, NOTEQUALS, LESSTHAN, LESSTHANOREQUAL, GREATERTHAN, GREATERTHANOREQUAL:
+			return comparisonPower
+		case CONCAT:
+			return 5
+		case PLUS, MINUS:
+			return 6
+		case ASTERISK, SLASH, PERCENT:
+			return 7
+		}
+	}
+	return 0
+}
+
+const (
+	// NOT binds looser than the comparisons but tighter than AND
+	notPower uint = 3
+	// [NOT] IN binds like the comparisons
+	comparisonPower uint = 4
+	// unary plus and minus bind tighter than any binary operator
+	signPower uint = 7
+)
+
+func parseExpression(tokens []*token, initialCursor uint, _ token) (*expression, uint, bool) {
+	return parseBinaryExpression(tokens, initialCursor, 0)
+}
+
+// parseBinaryExpression parses an expression using precedence climbing, only
+// consuming operators that bind tighter than minPower
+func parseBinaryExpression(tokens []*token, initialCursor uint, minPower uint) (*expression, uint, bool) {
+	cursor := initialCursor
+
+	exp, newCursor, ok := parseUnaryExpression(tokens, cursor)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	for cursor < uint(len(tokens)) {
+		if comparisonPower > minPower {
+			if in, newCursor, ok := parseInExpression(tokens, cursor, exp); ok {
+				exp = in
+				cursor = newCursor
+				continue
+			}
+			if isNull, newCursor, ok := parseIsNullExpression(tokens, cursor, exp); ok {
+				exp = isNull
+				cursor = newCursor
+				continue
+			}
+		}
+
+		op := tokens[cursor]
+		power := binaryOperatorPower(op)
+		if power == 0 || power <= minPower {
+			break
+		}
+		cursor++
+
+		b, newCursor, ok := parseBinaryExpression(tokens, cursor, power)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected right operand")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		exp = &expression{
+			binary: &binaryExpression{
+				a:  exp,
+				b:  b,
+				op: *op,
+			},
+			kind: binaryKind,
+		}
+	}
+	return exp, cursor, true
+}
+
+// parseInExpression parses the [NOT] IN (...) following its operand, the
+// parenthesis holding either a list of values or a subquery
+func parseInExpression(tokens []*token, initialCursor uint, operand *expression) (*expression, uint, bool) {
+	cursor := initialCursor
+	in := inExpression{operand: operand}
+
+	if expectToken(tokens, cursor, tokenFromKeyword(NOT)) {
+		in.not = true
+		cursor++
+	}
+	if !expectToken(tokens, cursor, tokenFromKeyword(IN)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(LEFTPAREN)) {
+		helpMessage(tokens, cursor, "Expected left paren")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	if slct, newCursor, ok := parseSelectStatement(tokens, cursor, tokenFromSymbol(RIGHTPAREN)); ok {
+		in.subquery = slct
+		cursor = newCursor
+	} else {
+		list, newCursor, ok := parseExpressionList(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected IN values")
+			return nil, initialCursor, false
+		}
+		in.list = *list
+		cursor = newCursor
+	}
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(RIGHTPAREN)) {
+		helpMessage(tokens, cursor, "Expected right paren")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	return &expression{
+		in:   &in,
+		kind: inKind,
+	}, cursor, true
+}
+
+// parseIsNullExpression parses the IS [NOT] NULL following its operand
+func parseIsNullExpression(tokens []*token, initialCursor uint, operand *expression) (*expression, uint, bool) {
+	cursor := initialCursor
+	if !expectToken(tokens, cursor, tokenFromKeyword(IS)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	isNull := isNullExpression{operand: operand}
+	if expectToken(tokens, cursor, tokenFromKeyword(NOT)) {
+		isNull.not = true
+		cursor++
+	}
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(NULL)) {
+		helpMessage(tokens, cursor, "Expected NULL")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	return &expression{
+		isNull: &isNull,
+		kind:   isNullKind,
+	}, cursor, true
+}
+
+// parseSubquery parses a parenthesised select
+func parseSubquery(tokens []*token, initialCursor uint) (*SelectStatement, uint, bool) {
+	cursor := initialCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(LEFTPAREN)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	slct, newCursor, ok := parseSelectStatement(tokens, cursor, tokenFromSymbol(RIGHTPAREN))
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(RIGHTPAREN)) {
+		helpMessage(tokens, cursor, "Expected right paren")
+		return nil, initialCursor, false
+	}
+	return slct, cursor + 1, true
+}
+
+func parseUnaryExpression(tokens []*token, initialCursor uint) (*expression, uint, bool) {
+	cursor := initialCursor
+
+	prefixes := []struct {
+		op    token
+		power uint
+	}{
+		{tokenFromKeyword(NOT), notPower},
+		{tokenFromSymbol(PLUS), signPower},
+		{tokenFromSymbol(MINUS), signPower},
+	}
+	for _, prefix := range prefixes {
+		if !expectToken(tokens, cursor, prefix.op) {
+			continue
+		}
+		op := tokens[cursor]
+		cursor++
+
+		operand, newCursor, ok := parseBinaryExpression(tokens, cursor, prefix.power)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected operand")
+			return nil, initialCursor, false
+		}
+		return &expression{
+			unary: &unaryExpression{
+				operand: operand,
+				op:      *op,
+			},
+			kind: unaryKind,
+		}, newCursor, true
+	}
+	return parsePrimaryExpression(tokens, cursor)
+}
+
+func parsePrimaryExpression(tokens []*token, initialCursor uint) (*expression, uint, bool) {
+	cursor := initialCursor
+
+	if slct, newCursor, ok := parseSubquery(tokens, cursor); ok {
+		return &expression{
+			subquery: slct,
+			kind:     subqueryKind,
+		}, newCursor, true
+	}
+
+	if expectToken(tokens, cursor, tokenFromKeyword(EXISTS)) {
+		cursor++
+		slct, newCursor, ok := parseSubquery(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected subquery")
+			return nil, initialCursor, false
+		}
+		return &expression{
+			subquery: slct,
+			kind:     existsKind,
+		}, newCursor, true
+	}
+
+	if expectToken(tokens, cursor, tokenFromSymbol(LEFTPAREN)) {
+		cursor++
+		exp, newCursor, ok := parseBinaryExpression(tokens, cursor, 0)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		if !expectToken(tokens, cursor, tokenFromSymbol(RIGHTPAREN)) {
+			helpMessage(tokens, cursor, "Expected right paren")
+			return nil, initialCursor, false
+		}
+		return exp, cursor + 1, true
+	}
+
+	// a string preceded by the name of its type, e.g. DATE '2024-01-01'
+	for _, kw := range []keyword{DATE, TIME, TIMESTAMP, INTERVAL} {
+		if !expectToken(tokens, cursor, tokenFromKeyword(kw)) {
+			continue
+		}
+		ty, newCursor, ok := parseDatatype(tokens, cursor)
+		if !ok {
+			break
+		}
+		if s, newCursor, ok := parseToken(tokens, newCursor, STRING); ok {
+			return &expression{
+				literal:  s,
+				typeName: ty,
+				kind:     literalKind,
+			}, newCursor, true
+		}
+	}
+
+	if t, newCursor, ok := parseIdentifier(tokens, cursor); ok {
+		if expectToken(tokens, newCursor, tokenFromSymbol(LEFTPAREN)) {
+			return parseFunctionCall(tokens, cursor)
+		}
+
+		// table.column
+		if expectToken(tokens, newCursor, tokenFromSymbol(DOT)) {
+			column, newCursor, ok := parseIdentifier(tokens, newCursor+1)
+			if !ok {
+				helpMessage(tokens, cursor, "Expected column name")
+				return nil, initialCursor, false
+			}
+			return &expression{
+				literal:   column,
+				qualifier: t,
+				kind:      literalKind,
+			}, newCursor, true
+		}
+
+		return &expression{
+			literal: t,
+			kind:    literalKind,
+		}, newCursor, true
+	}
+
+	for _, kw := range []keyword{NULL, TRUE, FALSE} {
+		if expectToken(tokens, cursor, tokenFromKeyword(kw)) {
+			return &expression{
+				literal: tokens[cursor],
+				kind:    literalKind,
+			}, cursor + 1, true
+		}
+	}
+
+	kinds := []tokenKind{NUMERIC, STRING, HEXSTRING}
+	for _, kind := range kinds {
+		t, newCursor, ok := parseToken(tokens, cursor, kind)
+		if ok {
+			return &expression{
+				literal: t,
+				kind:    literalKind,
+			}, newCursor, true
+		}
+	}
+
+	return nil, initialCursor, false
+}
+
+// parseFunctionCall parses name([DISTINCT] args...) or name(*)
+func parseFunctionCall(tokens []*token, initialCursor uint) (*expression, uint, bool) {
+	cursor := initialCursor
+
+	name, newCursor, ok := parseIdentifier(tokens, cursor)
+	if !ok {
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+	fn := functionCall{name: *name}
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(LEFTPAREN)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	// EXTRACT(field FROM source) passes the field as a string, as does
+	// date_part(field, source)
+	field, newCursor, ok := parseIdentifier(tokens, cursor)
+	if name.value == "extract" && ok && expectToken(tokens, newCursor, tokenFromKeyword(FROM)) {
+		cursor = newCursor + 1
+		source, newCursor, ok := parseBinaryExpression(tokens, cursor, 0)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected expression")
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+		fn.args = []*expression{
+			{
+				literal: &token{value: field.value, kind: STRING, loc: field.loc},
+				kind:    literalKind,
+			},
+			source,
+		}
+	} else if expectToken(tokens, cursor, tokenFromSymbol(ASTERISK)) {
+		fn.asterisk = true
+		cursor++
+	} else if !expectToken(tokens, cursor, tokenFromSymbol(RIGHTPAREN)) {
+		if expectToken(tokens, cursor, tokenFromKeyword(DISTINCT)) {
+			fn.distinct = true
+			cursor++
+		}
+
+		args, newCursor, ok := parseExpressionList(tokens, cursor)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected function arguments")
+			return nil, initialCursor, false
+		}
+		fn.args = *args
+		cursor = newCursor
+	}
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(RIGHTPAREN)) {
+		helpMessage(tokens, cursor, "Expected right paren")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	return &expression{
+		function: &fn,
+		kind:     functionKind,
+	}, cursor, true
+}
+
+func parseInsertStatement(tokens []*token, initialCursor uint, delimiter token) (*InsertStatement, uint, bool) {
+	cursor := initialCursor
+	if !expectToken(tokens, cursor, tokenFromKeyword(INSERT)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+	if !expectToken(tokens, cursor, tokenFromKeyword(INTO)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	table, newCursor, ok := parseIdentifier(tokens, cursor)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected table name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+	inst := InsertStatement{table: *table}
+
+	if expectToken(tokens, cursor, tokenFromSymbol(LEFTPAREN)) {
+		columns, newCursor, ok := parseColumnNames(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		inst.columns = *columns
+		cursor = newCursor
+	}
+
+	if expectToken(tokens, cursor, tokenFromKeyword(SELECT)) {
+		query, newCursor, ok := parseSelectStatement(tokens, cursor, delimiter)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		inst.query = query
+		return &inst, newCursor, true
+	}
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(VALUES)) {
+		helpMessage(tokens, cursor, "Expected VALUES or SELECT")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	for {
+		if !expectToken(tokens, cursor, tokenFromSymbol(LEFTPAREN)) {
+			helpMessage(tokens, cursor, "Expected left paren")
+			return nil, initialCursor, false
+		}
+		cursor++
+
+		values, newCursor, ok := parseExpressionList(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		cursor = newCursor
+
+		if !expectToken(tokens, cursor, tokenFromSymbol(RIGHTPAREN)) {
+			helpMessage(tokens, cursor, "Expected right paren")
+			return nil, initialCursor, false
+		}
+		cursor++
+		inst.values = append(inst.values, *values)
+
+		if !expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
+			break
+		}
+		cursor++
+	}
+
+	return &inst, cursor, true
+}
+
+func parseUpdateStatement(tokens []*token, initialCursor uint, delimiter token) (*UpdateStatement, uint, bool) {
+	cursor := initialCursor
+	if !expectToken(tokens, cursor, tokenFromKeyword(UPDATE)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	table, newCursor, ok := parseIdentifier(tokens, cursor)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected table name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(SET)) {
+		helpMessage(tokens, cursor, "Expected SET")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	upd := UpdateStatement{table: *table}
+	for {
+		set, newCursor, ok := parseSetClause(tokens, cursor)
+		if !ok {
+			return nil, initialCursor, false
+		}
+		upd.set = append(upd.set, set)
+		cursor = newCursor
+
+		if !expectToken(tokens, cursor, tokenFromSymbol(COMMA)) {
+			break
+		}
+		cursor++
+	}
+
+	if expectToken(tokens, cursor, tokenFromKeyword(WHERE)) {
+		cursor++
+		where, newCursor, ok := parseExpression(tokens, cursor, delimiter)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected WHERE conditionals")
+			return nil, initialCursor, false
+		}
+		upd.where = where
+		cursor = newCursor
+	}
+
+	return &upd, cursor, true
+}
+
+// parseSetClause parses a column = expression assignment of an UPDATE
+func parseSetClause(tokens []*token, initialCursor uint) (*setClause, uint, bool) {
+	cursor := initialCursor
+	column, newCursor, ok := parseIdentifier(tokens, cursor)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected column name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	if !expectToken(tokens, cursor, tokenFromSymbol(EQUALS)) {
+		helpMessage(tokens, cursor, "Expected =")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	value, newCursor, ok := parseExpression(tokens, cursor, tokenFromSymbol(COMMA))
+	if !ok {
+		helpMessage(tokens, cursor, "Expected SET value")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	return &setClause{
+		column: *column,
+		value:  value,
+	}, cursor, true
+}
+
+func parseDeleteStatement(tokens []*token, initialCursor uint, delimiter token) (*DeleteStatement, uint, bool) {
+	cursor := initialCursor
+	if !expectToken(tokens, cursor, tokenFromKeyword(DELETE)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	if !expectToken(tokens, cursor, tokenFromKeyword(FROM)) {
+		helpMessage(tokens, cursor, "Expected FROM")
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	table, newCursor, ok := parseIdentifier(tokens, cursor)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected table name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+	del := DeleteStatement{table: *table}
+
+	if expectToken(tokens, cursor, tokenFromKeyword(WHERE)) {
+		cursor++
+		where, newCursor, ok := parseExpression(tokens, cursor, delimiter)
+		if !ok {
+			helpMessage(tokens, cursor, "Expected WHERE conditionals")
+			return nil, initialCursor, false
+		}
+		del.where = where
+		cursor = newCursor
+	}
+
+	return &del, cursor, true
+}
+
+// parseTruncateStatement parses TRUNCATE [TABLE] name, a DELETE of every row
+func parseTruncateStatement(tokens []*token, initialCursor uint) (*DeleteStatement, uint, bool) {
+	cursor := initialCursor
+	if !expectToken(tokens, cursor, tokenFromKeyword(TRUNCATE)) {
+		return nil, initialCursor, false
+	}
+	cursor++
+
+	if expectToken(tokens, cursor, tokenFromKeyword(TABLE)) {
+		cursor++
+	}
+
+	table, newCursor, ok := parseIdentifier(tokens, cursor)
+	if !ok {
+		helpMessage(tokens, cursor, "Expected table name")
+		return nil, initialCursor, false
+	}
+	cursor = newCursor
+
+	return &DeleteStatement{table: *table}, cursor, true
 }
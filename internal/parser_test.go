@@ -0,0 +1,230 @@
+package godb
+
+import "testing"
+
+// parenthesize renders an expression with every operation in parentheses,
+// showing how the parser grouped it
+func parenthesize(exp *expression) string {
+	switch exp.kind {
+	case unaryKind:
+		return "(" + exp.unary.op.value + " " + parenthesize(exp.unary.operand) + ")"
+	case binaryKind:
+		bin := exp.binary
+		return "(" + parenthesize(bin.a) + " " + bin.op.value + " " + parenthesize(bin.b) + ")"
+	}
+	return exp.literal.value
+}
+
+func TestParseWhere(t *testing.T) {
+	tests := []struct {
+		where string
+		want  string
+	}{
+		{"a = 1", "(a = 1)"},
+		{"a = 1 OR b = 2 AND c = 3", "((a = 1) or ((b = 2) and (c = 3)))"},
+		{"(a = 1 OR b = 2) AND c = 3", "(((a = 1) or (b = 2)) and (c = 3))"},
+		{"NOT a = 1 AND b <> 2", "((not (a = 1)) and (b <> 2))"},
+		{"NOT NOT a >= 1", "(not (not (a >= 1)))"},
+		{"a < 1 AND b <= 2 AND c > 3", "(((a < 1) and (b <= 2)) and (c > 3))"},
+	}
+	for _, test := range tests {
+		ast, err := parse("SELECT a FROM t WHERE " + test.where + ";")
+		if err != nil {
+			t.Errorf("%s: %v", test.where, err)
+			continue
+		}
+		if got := parenthesize(ast.Statements[0].SelectStatement.where); got != test.want {
+			t.Errorf("%s: parsed as %s, want %s", test.where, got, test.want)
+		}
+	}
+
+	for _, where := range []string{"a =", "(a = 1", "a = 1 AND", "NOT"} {
+		if _, err := parse("SELECT a FROM t WHERE " + where + ";"); err == nil {
+			t.Errorf("%s: parsed", where)
+		}
+	}
+}
+
+func TestParseArithmetic(t *testing.T) {
+	tests := []struct {
+		item string
+		want string
+	}{
+		{"1 + 2 * 3", "(1 + (2 * 3))"},
+		{"(1 + 2) * 3", "((1 + 2) * 3)"},
+		{"1 - 2 - 3", "((1 - 2) - 3)"},
+		{"a / b % c", "((a / b) % c)"},
+		{"-a * b", "((- a) * b)"},
+		{"- -a", "(- (- a))"},
+		{"a || b + 1", "(a || (b + 1))"},
+		{"a + 1 = b * 2 OR NOT a || 'x' < b", "(((a + 1) = (b * 2)) or (not ((a || x) < b)))"},
+	}
+	for _, test := range tests {
+		ast, err := parse("SELECT " + test.item + " FROM t;")
+		if err != nil {
+			t.Errorf("%s: %v", test.item, err)
+			continue
+		}
+		if got := parenthesize(ast.Statements[0].SelectStatement.item[0].exp); got != test.want {
+			t.Errorf("%s: parsed as %s, want %s", test.item, got, test.want)
+		}
+	}
+}
+
+func TestParseSelectItems(t *testing.T) {
+	ast, err := parse("SELECT *, t.*, t, a * 2;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slct := ast.Statements[0].SelectStatement
+	if slct.from != nil {
+		t.Errorf("got a FROM clause, want none")
+	}
+	if len(slct.item) != 4 {
+		t.Fatalf("got %d select items, want 4", len(slct.item))
+	}
+	if item := slct.item[0]; !item.asterisk || item.table != nil {
+		t.Errorf("item 0 is not *")
+	}
+	if item := slct.item[1]; !item.asterisk || item.table == nil || item.table.value != "t" {
+		t.Errorf("item 1 is not t.*")
+	}
+	if item := slct.item[2]; item.asterisk || parenthesize(item.exp) != "t" {
+		t.Errorf("item 2 is not the column t")
+	}
+	if item := slct.item[3]; item.asterisk || parenthesize(item.exp) != "(a * 2)" {
+		t.Errorf("item 3 is not a * 2")
+	}
+}
+
+func TestParseOrderBy(t *testing.T) {
+	ast, err := parse("SELECT first FROM t ORDER BY a DESC, last NULLS FIRST, 2 ASC NULLS LAST, a * 2 DESC NULLS LAST;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		exp        string
+		desc       bool
+		nullsFirst bool
+	}{
+		// NULLs sort last ascending and first descending by default
+		{"a", true, true},
+		{"last", false, true},
+		{"2", false, false},
+		{"(a * 2)", true, false},
+	}
+	orderBy := ast.Statements[0].SelectStatement.orderBy
+	if len(orderBy) != len(want) {
+		t.Fatalf("got %d sort keys, want %d", len(orderBy), len(want))
+	}
+	for i, item := range orderBy {
+		if got := parenthesize(item.exp); got != want[i].exp || item.desc != want[i].desc || item.nullsFirst != want[i].nullsFirst {
+			t.Errorf("sort key %d: got %s desc=%t nullsFirst=%t, want %s desc=%t nullsFirst=%t",
+				i, got, item.desc, item.nullsFirst, want[i].exp, want[i].desc, want[i].nullsFirst)
+		}
+	}
+
+	if _, err := parse("SELECT a FROM t ORDER BY a NULLS;"); err == nil {
+		t.Errorf("NULLS without FIRST or LAST parsed")
+	}
+}
+
+// setOperations renders a select with its set operations in parentheses,
+// each single select shown by its first select item
+func setOperations(slct *SelectStatement) string {
+	if slct.setOp == nil {
+		return parenthesize(slct.item[0].exp)
+	}
+	op := slct.setOp.op.value
+	if slct.setOp.all {
+		op += " all"
+	}
+	return "(" + setOperations(slct.setOp.left) + " " + op + " " + setOperations(slct.setOp.right) + ")"
+}
+
+func TestParseSetOperations(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"SELECT 1 UNION SELECT 2 UNION ALL SELECT 3", "((1 union 2) union all 3)"},
+		{"SELECT 1 UNION SELECT 2 INTERSECT SELECT 3", "(1 union (2 intersect 3))"},
+		{"SELECT 1 INTERSECT ALL SELECT 2 EXCEPT DISTINCT SELECT 3", "((1 intersect all 2) except 3)"},
+	}
+	for _, test := range tests {
+		ast, err := parse(test.source + " ORDER BY 1 LIMIT 2;")
+		if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		slct := ast.Statements[0].SelectStatement
+		if got := setOperations(slct); got != test.want {
+			t.Errorf("%s: parsed as %s, want %s", test.source, got, test.want)
+		}
+		// ORDER BY and LIMIT apply to the whole set operation
+		if len(slct.orderBy) != 1 || slct.limit == nil {
+			t.Errorf("%s: ORDER BY or LIMIT is not on the set operation", test.source)
+		}
+	}
+}
+
+func TestParseAlterTable(t *testing.T) {
+	tests := []struct {
+		source  string
+		kind    alterKind
+		name    string
+		newName string
+	}{
+		{"ALTER TABLE t ADD COLUMN c int DEFAULT 1", addColumnAlterKind, "", ""},
+		{"ALTER TABLE t ADD c text", addColumnAlterKind, "", ""},
+		{"ALTER TABLE t DROP COLUMN c", dropColumnAlterKind, "c", ""},
+		{"ALTER TABLE t DROP c", dropColumnAlterKind, "c", ""},
+		{"ALTER TABLE t RENAME COLUMN c TO d", renameColumnAlterKind, "c", "d"},
+		{"ALTER TABLE t RENAME c TO d", renameColumnAlterKind, "c", "d"},
+		{"ALTER TABLE t RENAME TO u", renameTableAlterKind, "", "u"},
+	}
+	for _, test := range tests {
+		ast, err := parse(test.source + ";")
+		if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		alt := ast.Statements[0].AlterStatement
+		name, newName := "", ""
+		if alt.name != nil {
+			name = alt.name.value
+		}
+		if alt.newName != nil {
+			newName = alt.newName.value
+		}
+		if alt.table.value != "t" || alt.kind != test.kind || name != test.name || newName != test.newName {
+			t.Errorf("%s: parsed as kind %d of %s renaming %q to %q", test.source, alt.kind, alt.table.value, name, newName)
+		}
+	}
+
+	for _, source := range []string{"ALTER TABLE t ADD c", "ALTER TABLE t RENAME c", "ALTER TABLE t MODIFY c int", "ALTER t DROP c"} {
+		if _, err := parse(source + ";"); err == nil {
+			t.Errorf("%s: parsed", source)
+		}
+	}
+}
+
+func TestParseInsert(t *testing.T) {
+	ast, err := parse("INSERT INTO t (a, b) VALUES (1, 'x'), (2 + 3, 'y');")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := ast.Statements[0].InsertStatement
+	if len(inst.columns) != 2 || inst.columns[0].value != "a" || inst.columns[1].value != "b" {
+		t.Errorf("got %d columns, want a and b", len(inst.columns))
+	}
+	if len(inst.values) != 2 || len(inst.values[1]) != 2 || parenthesize(inst.values[1][0]) != "(2 + 3)" {
+		t.Errorf("got %d rows of values, want 2", len(inst.values))
+	}
+
+	for _, source := range []string{"INSERT INTO t () VALUES (1)", "INSERT INTO t VALUES (1),", "INSERT INTO t VALUES ()", "INSERT INTO t (a VALUES (1)"} {
+		if _, err := parse(source + ";"); err == nil {
+			t.Errorf("%s: parsed", source)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package godb
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
@@ -14,11 +15,17 @@ func REPL() {
 	for {
 		fmt.Print("> ")
 		text, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return
+		}
 		text = strings.Replace(text, "\n", "", -1)
 
+		// errors are reported and the session carries on, keeping the tables
+		// created so far
 		ast, err := parse(text)
 		if err != nil {
-			panic(err)
+			fmt.Println("error:", err)
+			continue
 		}
 
 		for _, stmt := range ast.Statements {
@@ -26,19 +33,50 @@ func REPL() {
 			case CreateStmtKind:
 				err := mb.CreateTable(stmt.CreateStatement)
 				if err != nil {
-					panic(err)
+					fmt.Println("error:", err)
+					continue
+				}
+				fmt.Println("ok")
+			case AlterStmtKind:
+				err := mb.AlterTable(stmt.AlterStatement)
+				if err != nil {
+					fmt.Println("error:", err)
+					continue
+				}
+				fmt.Println("ok")
+			case DropStmtKind:
+				err := mb.DropTable(stmt.DropStatement)
+				if err != nil {
+					fmt.Println("error:", err)
+					continue
 				}
 				fmt.Println("ok")
 			case InsertStmtKind:
 				err := mb.Insert(stmt.InsertStatement)
 				if err != nil {
-					panic(err)
+					fmt.Println("error:", err)
+					continue
 				}
 				fmt.Println("ok")
+			case UpdateStmtKind:
+				n, err := mb.Update(stmt.UpdateStatement)
+				if err != nil {
+					fmt.Println("error:", err)
+					continue
+				}
+				fmt.Printf("ok, %d rows updated\n", n)
+			case DeleteStmtKind:
+				n, err := mb.Delete(stmt.DeleteStatement)
+				if err != nil {
+					fmt.Println("error:", err)
+					continue
+				}
+				fmt.Printf("ok, %d rows deleted\n", n)
 			case SelectStmtKind:
 				results, err := mb.Select(stmt.SelectStatement)
 				if err != nil {
-					panic(err)
+					fmt.Println("error:", err)
+					continue
 				}
 				for _, col := range results.Columns {
 					fmt.Printf("| %s ", col.Name)
@@ -54,13 +92,9 @@ func REPL() {
 					fmt.Printf("|")
 
 					for i, cell := range result {
-						typ := results.Columns[i].Type
-						s := ""
-						switch typ {
-						case IntType:
-							s = fmt.Sprintf("%d", cell.AsInt())
-						case TextType:
-							s = cell.AsText()
+						s, err := formatCell(cell, results.Columns[i].Type)
+						if err != nil {
+							s = "error: " + err.Error()
 						}
 						fmt.Printf(" %s | ", s)
 					}
@@ -71,3 +105,36 @@ func REPL() {
 		}
 	}
 }
+
+// formatCell renders a cell of a column of the type typ
+func formatCell(cell Cell, typ ColumnType) (string, error) {
+	if cell.IsNull() {
+		return "NULL", nil
+	}
+	switch typ {
+	case IntType:
+		i, err := cell.AsInt()
+		return fmt.Sprintf("%d", i), err
+	case FloatType:
+		f, err := cell.AsFloat()
+		return formatFloat(f), err
+	case NumericType:
+		d, err := cell.AsDecimal()
+		return d.String(), err
+	case BoolType:
+		b, err := cell.AsBool()
+		return fmt.Sprintf("%t", b), err
+	case DateType, TimeType, TimestampType, TimestampTzType:
+		t, err := cell.AsTime()
+		return formatTime(t, typ), err
+	case IntervalType:
+		iv, err := cell.AsInterval()
+		return iv.String(), err
+	case BlobType:
+		b, err := cell.AsBytes()
+		return formatBlob(b), err
+	case TextType:
+		return cell.AsText()
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidDatatype, typ)
+}
@@ -0,0 +1,751 @@
+package godb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dates are stored as the days since 1970-01-01, times of day as the
+// microseconds since midnight and timestamps as the microseconds since
+// 1970-01-01 00:00:00 UTC. Timestamps without a time zone hold the wall clock
+// time as if it were in UTC, the time zone of the session.
+const (
+	usecPerSecond = int64(1000000)
+	usecPerMinute = 60 * usecPerSecond
+	usecPerHour   = 60 * usecPerMinute
+	usecPerDay    = 24 * usecPerHour
+)
+
+// minDate and maxDate are the first and last dates of the years 1 to 9999,
+// the range of dates and timestamps
+var (
+	minDate = timeDate(time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC))
+	maxDate = timeDate(time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC))
+)
+
+// Interval is a span of time in months, days and microseconds, kept apart
+// as months and days vary in length
+type Interval struct {
+	Months       int32
+	Days         int32
+	Microseconds int64
+}
+
+// String renders the interval as PostgreSQL does, e.g.
+// 1 year 2 mons 3 days 04:05:06
+func (iv Interval) String() string {
+	var parts []string
+	unit := func(n int32, name string) {
+		if n == 1 {
+			parts = append(parts, "1 "+name)
+		} else if n != 0 {
+			parts = append(parts, fmt.Sprintf("%d %ss", n, name))
+		}
+	}
+	unit(iv.Months/12, "year")
+	unit(iv.Months%12, "mon")
+	unit(iv.Days, "day")
+
+	if iv.Microseconds != 0 || len(parts) == 0 {
+		usec, sign := iv.Microseconds, ""
+		if usec < 0 {
+			usec, sign = -usec, "-"
+		}
+		s := fmt.Sprintf("%s%02d:%02d:%02d", sign, usec/usecPerHour, usec/usecPerMinute%60, usec/usecPerSecond%60)
+		if frac := usec % usecPerSecond; frac != 0 {
+			s += strings.TrimRight(fmt.Sprintf(".%06d", frac), "0")
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+// span is the length of the interval in days and the microseconds left
+// over, taking months as 30 days, by which intervals are compared
+func (iv Interval) span() (int64, int64) {
+	days := floorDiv(iv.Microseconds, usecPerDay)
+	return int64(iv.Months)*30 + int64(iv.Days) + days, iv.Microseconds - days*usecPerDay
+}
+
+// scale multiplies the interval, cascading fractions of months into days and
+// fractions of days into microseconds
+func (iv Interval) scale(f float64) (Interval, error) {
+	months := float64(iv.Months) * f
+	days := float64(iv.Days)*f + (months-math.Trunc(months))*30
+	usec := float64(iv.Microseconds)*f + (days-math.Trunc(days))*float64(usecPerDay)
+	return floatInterval(months, days, math.Round(usec))
+}
+
+// negate returns the interval with the sign of each of its fields flipped
+func (iv Interval) negate() (Interval, error) {
+	return newInterval(-int64(iv.Months), -int64(iv.Days), -iv.Microseconds)
+}
+
+// newInterval returns the interval of the months, days and microseconds,
+// failing if they do not fit its fields. The least microseconds are out of
+// range too, so that every interval can be negated.
+func newInterval(months, days, usec int64) (Interval, error) {
+	if months != int64(int32(months)) || days != int64(int32(days)) || usec == math.MinInt64 {
+		return Interval{}, ErrIntervalOutOfRange
+	}
+	return Interval{Months: int32(months), Days: int32(days), Microseconds: usec}, nil
+}
+
+// floatInterval returns the interval of the months, days and microseconds,
+// truncated, failing if they do not fit its fields
+func floatInterval(months, days, usec float64) (Interval, error) {
+	// floats of at least 2^63 in magnitude, and NaN, are out of the range of
+	// an int64
+	for _, f := range []float64{months, days, usec} {
+		if !(f > -(1<<63) && f < 1<<63) {
+			return Interval{}, ErrIntervalOutOfRange
+		}
+	}
+	return newInterval(int64(months), int64(days), int64(usec))
+}
+
+// addIntervals adds the intervals field by field
+func addIntervals(x, y Interval) (Interval, error) {
+	usec := x.Microseconds + y.Microseconds
+	if (usec > x.Microseconds) != (y.Microseconds > 0) {
+		return Interval{}, ErrIntervalOutOfRange
+	}
+	return newInterval(int64(x.Months)+int64(y.Months), int64(x.Days)+int64(y.Days), usec)
+}
+
+// AsTime decodes a DATE, TIME or TIMESTAMP cell as a time in UTC, dates
+// being at midnight and times of day on 1970-01-01
+func (mc MemoryCell) AsTime() (time.Time, error) {
+	switch {
+	case mc == nil:
+		return time.Time{}, ErrNullCell
+	case len(mc) == 4:
+		return dateTime(mc.date()), nil
+	case len(mc) == 8:
+		return usecTime(mc.int()), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: %d bytes are not a date or time", ErrInvalidCell, len(mc))
+}
+
+// AsInterval decodes the cell as an interval, failing if it is NULL or does
+// not hold one
+func (mc MemoryCell) AsInterval() (Interval, error) {
+	if mc == nil {
+		return Interval{}, ErrNullCell
+	}
+	if len(mc) != 16 {
+		return Interval{}, fmt.Errorf("%w: %d bytes are not an interval", ErrInvalidCell, len(mc))
+	}
+	return mc.interval(), nil
+}
+
+// date decodes a cell known to hold a date
+func (mc MemoryCell) date() int32 {
+	return int32(binary.BigEndian.Uint32(mc))
+}
+
+// interval decodes a cell known to hold an interval
+func (mc MemoryCell) interval() Interval {
+	return Interval{
+		Months:       int32(binary.BigEndian.Uint32(mc)),
+		Days:         int32(binary.BigEndian.Uint32(mc[4:])),
+		Microseconds: int64(binary.BigEndian.Uint64(mc[8:])),
+	}
+}
+
+func dateCell(days int32) MemoryCell {
+	c := make(MemoryCell, 4)
+	binary.BigEndian.PutUint32(c, uint32(days))
+	return c
+}
+
+func intervalCell(iv Interval) MemoryCell {
+	c := make(MemoryCell, 16)
+	binary.BigEndian.PutUint32(c, uint32(iv.Months))
+	binary.BigEndian.PutUint32(c[4:], uint32(iv.Days))
+	binary.BigEndian.PutUint64(c[8:], uint64(iv.Microseconds))
+	return c
+}
+
+// isTemporalType reports whether values of the type are dates, times or
+// intervals
+func isTemporalType(typ ColumnType) bool {
+	switch typ {
+	case DateType, TimeType, TimestampType, TimestampTzType, IntervalType:
+		return true
+	}
+	return false
+}
+
+// floorDiv divides rounding towards negative infinity, so that times before
+// 1970 fall on the right day
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func dateTime(days int32) time.Time {
+	return time.Unix(int64(days)*86400, 0).UTC()
+}
+
+func usecTime(usec int64) time.Time {
+	sec := floorDiv(usec, usecPerSecond)
+	return time.Unix(sec, (usec-sec*usecPerSecond)*1000).UTC()
+}
+
+func timeUsec(t time.Time) int64 {
+	return t.Unix()*usecPerSecond + int64(t.Nanosecond()/1000)
+}
+
+func timeDate(t time.Time) int32 {
+	return int32(floorDiv(t.Unix(), 86400))
+}
+
+// dateResult encodes a computed date, failing unless it falls in the years 1
+// to 9999, those ISO-8601 writes with four digits
+func dateResult(days int64) (MemoryCell, error) {
+	if days < int64(minDate) || days > int64(maxDate) {
+		return nil, fmt.Errorf("%w: %d days from 1970-01-01", ErrDateOutOfRange, days)
+	}
+	return dateCell(int32(days)), nil
+}
+
+// timestampResult encodes a computed timestamp, failing unless it falls in
+// the years 1 to 9999
+func timestampResult(t time.Time) (MemoryCell, error) {
+	if year := t.Year(); year < 1 || year > 9999 {
+		return nil, fmt.Errorf("%w: year %d", ErrDateOutOfRange, year)
+	}
+	return intCell(timeUsec(t)), nil
+}
+
+// cellTime decodes a cell of a date or time type
+func cellTime(c MemoryCell, typ ColumnType) time.Time {
+	if typ == DateType {
+		return dateTime(c.date())
+	}
+	return usecTime(c.int())
+}
+
+// formatTime renders a date or time of the type typ in ISO-8601
+func formatTime(t time.Time, typ ColumnType) string {
+	switch typ {
+	case DateType:
+		return t.Format("2006-01-02")
+	case TimeType:
+		return t.Format("15:04:05.999999")
+	case TimestampTzType:
+		return t.Format("2006-01-02 15:04:05.999999-07")
+	}
+	return t.Format("2006-01-02 15:04:05.999999")
+}
+
+// parseTemporal reads an ISO-8601 date, time, timestamp or interval
+func parseTemporal(s string, typ ColumnType) (MemoryCell, error) {
+	s = strings.TrimSpace(s)
+	if typ == IntervalType {
+		iv, err := parseInterval(s)
+		if err != nil {
+			return nil, err
+		}
+		return intervalCell(iv), nil
+	}
+
+	if typ == TimeType {
+		for _, layout := range []string{"15:04:05", "15:04"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				usec := int64(t.Hour())*usecPerHour + int64(t.Minute())*usecPerMinute + int64(t.Second())*usecPerSecond
+				return intCell(usec + int64(t.Nanosecond()/1000)), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: '%s' is not a time", ErrInvalidCell, s)
+	}
+
+	// the date and time may be separated by a T, the time may be followed by
+	// an offset from UTC
+	if len(s) > 10 && s[10] == 'T' {
+		s = s[:10] + " " + s[11:]
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		for _, zone := range []string{"", "Z07:00", "-07", "-0700"} {
+			t, err := time.Parse(layout+zone, s)
+			if err != nil {
+				continue
+			}
+
+			// dates and timestamps without a time zone ignore the offset
+			wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+			switch typ {
+			case DateType:
+				return dateResult(int64(timeDate(wall)))
+			case TimestampType:
+				return timestampResult(wall)
+			}
+			return timestampResult(t.UTC())
+		}
+	}
+	return nil, fmt.Errorf("%w: '%s' is not a %s", ErrInvalidCell, s, typ)
+}
+
+// intervalUnits are the units of intervals, in months, days or microseconds
+var intervalUnits = map[string]struct {
+	months, days float64
+	usec         int64
+}{
+	"microsecond": {usec: 1},
+	"millisecond": {usec: 1000},
+	"second":      {usec: usecPerSecond},
+	"sec":         {usec: usecPerSecond},
+	"minute":      {usec: usecPerMinute},
+	"min":         {usec: usecPerMinute},
+	"hour":        {usec: usecPerHour},
+	"day":         {days: 1},
+	"week":        {days: 7},
+	"month":       {months: 1},
+	"mon":         {months: 1},
+	"year":        {months: 12},
+	"decade":      {months: 120},
+	"century":     {months: 1200},
+	"centuries":   {months: 1200},
+	"millennium":  {months: 12000},
+	"millennia":   {months: 12000},
+}
+
+// addIntervalUnit adds n of the unit to the interval, cascading fractions of
+// months and days down, false if there is no such unit
+func addIntervalUnit(iv *Interval, n float64, unit string) (bool, error) {
+	u, ok := intervalUnits[unit]
+	if !ok {
+		u, ok = intervalUnits[strings.TrimSuffix(unit, "s")]
+	}
+	if !ok {
+		return false, nil
+	}
+
+	months := n * u.months
+	days := n*u.days + (months-math.Trunc(months))*30
+	add, err := floatInterval(months, days, math.Round(n*float64(u.usec)+(days-math.Trunc(days))*float64(usecPerDay)))
+	if err != nil {
+		return true, err
+	}
+	*iv, err = addIntervals(*iv, add)
+	return true, err
+}
+
+// parseInterval reads an interval written as quantities of units with an
+// optional time of day, e.g. 1 year 2 mons 3 days 04:05:06, or in ISO-8601,
+// e.g. P1Y2M3DT4H5M6S
+func parseInterval(s string) (Interval, error) {
+	invalid := fmt.Errorf("%w: '%s' is not an interval", ErrInvalidCell, s)
+	outOfRange := fmt.Errorf("%w: '%s'", ErrIntervalOutOfRange, s)
+	if strings.HasPrefix(s, "P") {
+		return parseISOInterval(s, invalid, outOfRange)
+	}
+
+	var iv Interval
+	fields := strings.Fields(strings.ToLower(s))
+	ago := len(fields) > 0 && fields[len(fields)-1] == "ago"
+	if ago {
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) == 0 {
+		return iv, invalid
+	}
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if strings.Contains(field, ":") {
+			usec, ok := parseIntervalTime(field)
+			if !ok {
+				return iv, invalid
+			}
+			add, err := floatInterval(0, 0, usec)
+			if err == nil {
+				iv, err = addIntervals(iv, add)
+			}
+			if err != nil {
+				return iv, outOfRange
+			}
+			continue
+		}
+
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil || i+1 == len(fields) {
+			return iv, invalid
+		}
+		if ok, err := addIntervalUnit(&iv, n, fields[i+1]); !ok {
+			return iv, invalid
+		} else if err != nil {
+			return iv, outOfRange
+		}
+		i++
+	}
+
+	if ago {
+		var err error
+		if iv, err = iv.negate(); err != nil {
+			return iv, outOfRange
+		}
+	}
+	return iv, nil
+}
+
+// parseIntervalTime reads the [-]hh:mm[:ss[.ffffff]] of an interval as
+// microseconds
+func parseIntervalTime(s string) (float64, bool) {
+	sign := 1.0
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, s[1:]
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	var usec float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || (i < len(parts)-1 && strings.Contains(part, ".")) {
+			return 0, false
+		}
+		usec += math.Round(n * float64([]int64{usecPerHour, usecPerMinute, usecPerSecond}[i]))
+	}
+	return sign * usec, true
+}
+
+// parseISOInterval reads an ISO-8601 duration, e.g. P1Y2M3DT4H5M6S
+func parseISOInterval(s string, invalid, outOfRange error) (Interval, error) {
+	var iv Interval
+	units := map[byte]string{'Y': "year", 'M': "month", 'W': "week", 'D': "day"}
+	number := ""
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9' || c == '.' || c == '-':
+			number += string(c)
+			continue
+		case c == 'T':
+			if number != "" {
+				return iv, invalid
+			}
+			units = map[byte]string{'H': "hour", 'M': "minute", 'S': "second"}
+			continue
+		}
+
+		n, err := strconv.ParseFloat(number, 64)
+		unit, ok := units[c]
+		if err != nil || !ok {
+			return iv, invalid
+		}
+		if _, err := addIntervalUnit(&iv, n, unit); err != nil {
+			return iv, outOfRange
+		}
+		number = ""
+	}
+	if number != "" || len(s) == 1 {
+		return iv, invalid
+	}
+	return iv, nil
+}
+
+// addInterval adds the interval to a time, months first so that the day of
+// the month is kept, or the last day of the month if it has fewer
+func addInterval(t time.Time, iv Interval) time.Time {
+	if iv.Months != 0 {
+		year, month, day := t.Date()
+		months := int(month) - 1 + int(iv.Months)
+		year += int(floorDiv(int64(months), 12))
+		month = time.Month(months-int(floorDiv(int64(months), 12))*12) + 1
+		if last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day(); day > last {
+			day = last
+		}
+		t = time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	// whole days of microseconds are added as days, as a duration holds
+	// fewer microseconds than an interval can
+	days := floorDiv(iv.Microseconds, usecPerDay)
+	usec := iv.Microseconds - days*usecPerDay
+	return t.AddDate(0, 0, int(iv.Days)+int(days)).Add(time.Duration(usec) * time.Microsecond)
+}
+
+// temporalOperator is an arithmetic operator on operands of the types a and
+// b, at least one of them temporal
+type temporalOperator struct {
+	op     symbol
+	a, b   ColumnType
+	result ColumnType
+}
+
+var temporalOperators = []temporalOperator{
+	{PLUS, DateType, IntType, DateType},
+	{PLUS, IntType, DateType, DateType},
+	{PLUS, DateType, IntervalType, TimestampType},
+	{PLUS, IntervalType, DateType, TimestampType},
+	{PLUS, DateType, TimeType, TimestampType},
+	{PLUS, TimeType, DateType, TimestampType},
+	{PLUS, TimestampType, IntervalType, TimestampType},
+	{PLUS, IntervalType, TimestampType, TimestampType},
+	{PLUS, TimestampTzType, IntervalType, TimestampTzType},
+	{PLUS, IntervalType, TimestampTzType, TimestampTzType},
+	{PLUS, TimeType, IntervalType, TimeType},
+	{PLUS, IntervalType, TimeType, TimeType},
+	{PLUS, IntervalType, IntervalType, IntervalType},
+	{MINUS, DateType, IntType, DateType},
+	{MINUS, DateType, DateType, IntType},
+	{MINUS, DateType, IntervalType, TimestampType},
+	{MINUS, TimestampType, IntervalType, TimestampType},
+	{MINUS, TimestampTzType, IntervalType, TimestampTzType},
+	{MINUS, TimeType, IntervalType, TimeType},
+	{MINUS, TimestampType, TimestampType, IntervalType},
+	{MINUS, TimestampTzType, TimestampTzType, IntervalType},
+	{MINUS, TimeType, TimeType, IntervalType},
+	{MINUS, IntervalType, IntervalType, IntervalType},
+	{ASTERISK, IntervalType, FloatType, IntervalType},
+	{ASTERISK, FloatType, IntervalType, IntervalType},
+	{SLASH, IntervalType, FloatType, IntervalType},
+}
+
+// resolveTemporalOperator finds the operator for operands of the types a and
+// b, preferring one taking the types as they are to one they must be
+// promoted for. NULL operands match any type.
+func resolveTemporalOperator(op token, a, b ColumnType) (*temporalOperator, error) {
+	matches := func(typ, operand ColumnType, promote bool) bool {
+		return typ == nullType || typ == operand || (promote && promotes(typ, operand))
+	}
+	for _, promote := range []bool{false, true} {
+		for i, o := range temporalOperators {
+			if symbol(op.value) == o.op && matches(a, o.a, promote) && matches(b, o.b, promote) {
+				return &temporalOperators[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %s %s", ErrInvalidOperands, a, op.value, b)
+}
+
+// evaluateTemporalArithmetic applies an arithmetic operator to operands at
+// least one of which is a date, time or interval
+func evaluateTemporalArithmetic(op token, a MemoryCell, aType ColumnType, b MemoryCell, bType ColumnType) (MemoryCell, ColumnType, error) {
+	o, err := resolveTemporalOperator(op, aType, bType)
+	if err != nil {
+		return nil, 0, err
+	}
+	if a == nil || b == nil {
+		return nil, o.result, nil
+	}
+	a, b = convertCell(a, aType, o.a), convertCell(b, bType, o.b)
+
+	switch {
+	case o.a == DateType && o.b == IntType, o.a == IntType && o.b == DateType:
+		date, n := a, b.int()
+		if o.a == IntType {
+			date, n = b, a.int()
+		}
+		if n != int64(int32(n)) {
+			return nil, 0, fmt.Errorf("%w: %d days", ErrIntegerOutOfRange, n)
+		}
+		if o.op == MINUS {
+			n = -n
+		}
+		c, err := dateResult(int64(date.date()) + n)
+		return c, DateType, err
+	case o.a == DateType && o.b == DateType:
+		return intCell(int64(a.date() - b.date())), IntType, nil
+	case o.a == DateType && o.b == TimeType:
+		return intCell(int64(a.date())*usecPerDay + b.int()), TimestampType, nil
+	case o.a == TimeType && o.b == DateType:
+		return intCell(int64(b.date())*usecPerDay + a.int()), TimestampType, nil
+	case o.a == IntervalType && o.b == IntervalType:
+		x, y := a.interval(), b.interval()
+		if o.op == MINUS {
+			if y, err = y.negate(); err != nil {
+				return nil, 0, err
+			}
+		}
+		iv, err := addIntervals(x, y)
+		if err != nil {
+			return nil, 0, err
+		}
+		return intervalCell(iv), IntervalType, nil
+	case o.a == FloatType || o.b == FloatType:
+		iv, f := a.interval(), b.float()
+		if o.a == FloatType {
+			iv, f = b.interval(), a.float()
+		}
+		if o.op == SLASH {
+			if f == 0 {
+				return nil, 0, ErrDivisionByZero
+			}
+			f = 1 / f
+		}
+		if iv, err = iv.scale(f); err != nil {
+			return nil, 0, err
+		}
+		return intervalCell(iv), IntervalType, nil
+	case o.result == IntervalType:
+		// the difference of two times, in days and microseconds
+		usec := a.int() - b.int()
+		if o.a == TimeType {
+			return intervalCell(Interval{Microseconds: usec}), IntervalType, nil
+		}
+		iv, err := newInterval(0, usec/usecPerDay, usec%usecPerDay)
+		if err != nil {
+			return nil, 0, err
+		}
+		return intervalCell(iv), IntervalType, nil
+	}
+
+	// a time plus or minus an interval, in either order
+	t, tType, iv := a, o.a, b.interval()
+	if o.a == IntervalType {
+		t, tType, iv = b, o.b, a.interval()
+	}
+	if o.op == MINUS {
+		if iv, err = iv.negate(); err != nil {
+			return nil, 0, err
+		}
+	}
+	switch tType {
+	case TimeType:
+		usec := (t.int() + iv.Microseconds%usecPerDay) % usecPerDay
+		if usec < 0 {
+			usec += usecPerDay
+		}
+		return intCell(usec), TimeType, nil
+	case DateType:
+		c, err := timestampResult(addInterval(dateTime(t.date()), iv))
+		return c, TimestampType, err
+	}
+	c, err := timestampResult(addInterval(usecTime(t.int()), iv))
+	return c, tType, err
+}
+
+// truncateTime truncates a time to the precision of the field
+func truncateTime(t time.Time, field string) (time.Time, error) {
+	year, month, day := t.Date()
+	switch field {
+	case "microseconds":
+		return t.Truncate(time.Microsecond), nil
+	case "milliseconds":
+		return t.Truncate(time.Millisecond), nil
+	case "second":
+		return t.Truncate(time.Second), nil
+	case "minute":
+		return t.Truncate(time.Minute), nil
+	case "hour":
+		return t.Truncate(time.Hour), nil
+	case "day":
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), nil
+	case "week":
+		// weeks start on Monday
+		weekday := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-weekday, 0, 0, 0, 0, time.UTC), nil
+	case "month":
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), nil
+	case "quarter":
+		return time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, time.UTC), nil
+	case "year":
+		return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	case "decade":
+		return time.Date(year-year%10, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	case "century":
+		return time.Date(year-(year-1)%100, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	case "millennium":
+		return time.Date(year-(year-1)%1000, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return t, fmt.Errorf("%w: %s", ErrInvalidField, field)
+}
+
+// extractField returns a field of a date, time or timestamp
+func extractField(t time.Time, typ ColumnType, field string) (float64, error) {
+	seconds := float64(t.Second()) + float64(t.Nanosecond()/1000)/float64(usecPerSecond)
+	switch field {
+	case "microseconds":
+		return seconds * float64(usecPerSecond), nil
+	case "milliseconds":
+		return seconds * 1000, nil
+	case "second":
+		return seconds, nil
+	case "minute":
+		return float64(t.Minute()), nil
+	case "hour":
+		return float64(t.Hour()), nil
+	case "epoch":
+		return float64(timeUsec(t)) / float64(usecPerSecond), nil
+	}
+
+	// the remaining fields are those of dates
+	if typ == TimeType {
+		return 0, fmt.Errorf("%w: %s of a time", ErrInvalidField, field)
+	}
+	year, week := t.ISOWeek()
+	switch field {
+	case "day":
+		return float64(t.Day()), nil
+	case "dow":
+		return float64(t.Weekday()), nil
+	case "isodow":
+		return float64((int(t.Weekday())+6)%7 + 1), nil
+	case "doy":
+		return float64(t.YearDay()), nil
+	case "week":
+		return float64(week), nil
+	case "isoyear":
+		return float64(year), nil
+	case "month":
+		return float64(t.Month()), nil
+	case "quarter":
+		return float64((t.Month()-1)/3 + 1), nil
+	case "year":
+		return float64(t.Year()), nil
+	case "decade":
+		return float64(t.Year() / 10), nil
+	case "century":
+		return float64((t.Year()-1)/100 + 1), nil
+	case "millennium":
+		return float64((t.Year()-1)/1000 + 1), nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrInvalidField, field)
+}
+
+// extractIntervalField returns a field of an interval
+func extractIntervalField(iv Interval, field string) (float64, error) {
+	seconds := float64(iv.Microseconds%usecPerMinute) / float64(usecPerSecond)
+	switch field {
+	case "microseconds":
+		return seconds * float64(usecPerSecond), nil
+	case "milliseconds":
+		return seconds * 1000, nil
+	case "second":
+		return seconds, nil
+	case "minute":
+		return float64(iv.Microseconds / usecPerMinute % 60), nil
+	case "hour":
+		return float64(iv.Microseconds / usecPerHour), nil
+	case "day":
+		return float64(iv.Days), nil
+	case "month":
+		return float64(iv.Months % 12), nil
+	case "quarter":
+		return float64(iv.Months%12/3 + 1), nil
+	case "year":
+		return float64(iv.Months / 12), nil
+	case "decade":
+		return float64(iv.Months / 120), nil
+	case "century":
+		return float64(iv.Months / 1200), nil
+	case "millennium":
+		return float64(iv.Months / 12000), nil
+	case "epoch":
+		// years count 365.25 days, the remaining months 30
+		days := float64(iv.Months/12)*365.25 + float64(iv.Months%12)*30 + float64(iv.Days)
+		return days*86400 + float64(iv.Microseconds)/float64(usecPerSecond), nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrInvalidField, field)
+}
@@ -0,0 +1,105 @@
+package godb
+
+import "testing"
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"1 day", "1 day"},
+		{"1 year 2 months 3 days 04:05:06", "1 year 2 mons 3 days 04:05:06"},
+		{"90 minutes", "01:30:00"},
+		{"1.5 days", "1 day 12:00:00"},
+		{"2 weeks ago", "-14 days"},
+		{"P1Y2M3DT4H5M6S", "1 year 2 mons 3 days 04:05:06"},
+		{"00:00:00.25", "00:00:00.25"},
+	}
+	for _, test := range tests {
+		iv, err := parseInterval(test.s)
+		if err != nil {
+			t.Errorf("parseInterval(%q): %v", test.s, err)
+			continue
+		}
+		if iv.String() != test.want {
+			t.Errorf("parseInterval(%q) = %s, want %s", test.s, iv, test.want)
+		}
+	}
+
+	for _, s := range []string{"", "1 fortnight", "day", "P1X"} {
+		if _, err := parseInterval(s); err == nil {
+			t.Errorf("parseInterval(%q) did not fail", s)
+		}
+	}
+}
+
+func TestTemporalArithmetic(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "SELECT date '2024-02-28' + 2, date '2024-03-01' - date '2024-02-01', date '2024-03-01' - 1;", rows: []string{"2024-03-01, 29, 2024-02-29"}},
+		{sql: "SELECT date '2024-01-31' + interval '1 month', timestamp '2023-01-31 10:00:00' + interval '1 month';", rows: []string{"2024-02-29 00:00:00, 2023-02-28 10:00:00"}},
+		{sql: "SELECT date '2024-01-01' + time '12:30:00', time '23:00:00' + interval '2 hours';", rows: []string{"2024-01-01 12:30:00, 01:00:00"}},
+		{sql: "SELECT timestamp '2024-01-02 06:00:00' - timestamp '2024-01-01 00:00:00';", rows: []string{"1 day 06:00:00"}},
+		{sql: "SELECT interval '1 day' * 1.5, interval '3 hours' / 2, -interval '1 day', interval '1 month' + interval '1 day';", rows: []string{"1 day 12:00:00, 01:30:00, -1 days, 1 mon 1 day"}},
+		{sql: "SELECT timestamp with time zone '2024-01-01 12:00:00+02' + interval '1 hour';", rows: []string{"2024-01-01 11:00:00+00"}},
+		{sql: "SELECT date '2024-01-01' + date '2024-01-02';", err: ErrInvalidOperands},
+		{sql: "SELECT interval '1 day' * interval '1 day';", err: ErrInvalidOperands},
+
+		// dates are promoted to timestamps, and string literals read as the
+		// type they are compared with
+		{sql: "SELECT date '2024-01-01' < timestamp '2024-01-01 00:00:01', interval '1 month' = interval '30 days';", rows: []string{"true, true"}},
+		{sql: "CREATE TABLE events (id int, at date, span interval);"},
+		{sql: "INSERT INTO events VALUES (1, '2024-01-15', '1 day'), (2, '2024-02-15', '2 hours');"},
+		{sql: "INSERT INTO events VALUES (3, '2024-02-30', '1 day');", err: ErrValueTypeMismatch},
+		{sql: "SELECT id FROM events WHERE at > '2024-02-01';", rows: []string{"2"}},
+		{sql: "SELECT at + span FROM events ORDER BY id;", rows: []string{"2024-01-16 00:00:00", "2024-02-15 02:00:00"}},
+		{sql: "SELECT max(at), min(span) FROM events;", rows: []string{"2024-02-15, 02:00:00"}},
+	})
+}
+
+func TestDateFunctions(t *testing.T) {
+	runSQL(t, []sqlTest{
+		{sql: "SELECT extract(year FROM date '2024-03-15'), date_part('dow', date '2024-03-15'), extract(epoch FROM interval '1 day');", rows: []string{"2024, 5, 86400"}},
+		{sql: "SELECT extract(month FROM interval '1 year 2 months'), extract(second FROM time '10:20:30.5');", rows: []string{"2, 30.5"}},
+		{sql: "SELECT date_trunc('month', timestamp '2024-03-15 10:20:30'), date_trunc('hour', date '2024-03-15');", rows: []string{"2024-03-01 00:00:00, 2024-03-15 00:00:00"}},
+		{sql: "SELECT extract(fortnight FROM date '2024-03-15');", err: ErrInvalidField},
+		{sql: "SELECT date_trunc('month', NULL), extract(year FROM NULL);", rows: []string{"NULL, NULL"}},
+		{sql: "SELECT date_trunc('month', 1);", err: ErrInvalidArguments},
+	})
+}
+
+func TestIntervalKeys(t *testing.T) {
+	runSQL(t, []sqlTest{
+		// intervals that compare equal group, join and collide alike
+		{sql: "CREATE TABLE iv (i interval);"},
+		{sql: "INSERT INTO iv VALUES (interval '1 month'), (interval '30 days');"},
+		{sql: "SELECT count(*) FROM iv a, iv b WHERE a.i = b.i;", rows: []string{"4"}},
+		{sql: "SELECT count(*) FROM iv a JOIN iv b ON a.i = b.i;", rows: []string{"4"}},
+		{sql: "SELECT count(*) FROM iv GROUP BY i;", rows: []string{"2"}},
+		{sql: "SELECT count(DISTINCT i) FROM iv;", rows: []string{"1"}},
+		{sql: "SELECT i FROM iv UNION SELECT interval '720 hours';", rows: []string{"1 mon"}},
+		{sql: "CREATE TABLE ivu (i interval UNIQUE);"},
+		{sql: "INSERT INTO ivu VALUES (interval '1 month'), (interval '30 days');", err: ErrUniqueViolation},
+	})
+}
+
+func TestTemporalRange(t *testing.T) {
+	runSQL(t, []sqlTest{
+		// dates, timestamps and intervals out of range fail rather than wrap
+		// around
+		{sql: "SELECT date '2024-01-01' + 9223372036854775807;", err: ErrIntegerOutOfRange},
+		{sql: "SELECT date '2024-01-01' - 4294967296;", err: ErrIntegerOutOfRange},
+		{sql: "SELECT date '9999-12-31' + 1;", err: ErrDateOutOfRange},
+		{sql: "SELECT date '0001-01-01' - 1;", err: ErrDateOutOfRange},
+		{sql: "SELECT date '0000-12-31';", err: ErrDateOutOfRange},
+		{sql: "SELECT timestamp '9999-12-31 23:00:00' + interval '1 hour';", err: ErrDateOutOfRange},
+		{sql: "SELECT date '2024-01-01' + interval '9000 years';", err: ErrDateOutOfRange},
+		{sql: "SELECT date_trunc('day', timestamp '9999-12-31 23:00:00'), date '9999-12-30' + 1;", rows: []string{"9999-12-31 00:00:00, 9999-12-31"}},
+		{sql: "SELECT interval '9999999999999 years';", err: ErrIntervalOutOfRange},
+		{sql: "SELECT interval '2147483647 days' + interval '1 day';", err: ErrIntervalOutOfRange},
+		{sql: "SELECT interval '-2147483648 months' - interval '1 month';", err: ErrIntervalOutOfRange},
+		{sql: "SELECT -interval '-2147483648 days';", err: ErrIntervalOutOfRange},
+		{sql: "SELECT interval '2 months' * 2147483647;", err: ErrIntervalOutOfRange},
+		{sql: "SELECT interval '1 day' / 0.0000000001;", err: ErrIntervalOutOfRange},
+		{sql: "SELECT interval '2147483647 months' > interval '-2147483648 months', interval '2147483647 days' > interval '-2147483648 days';", rows: []string{"true, true"}},
+	})
+}